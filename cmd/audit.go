@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var auditSince string
+var auditConfigName string
+var auditAccount string
+var auditRole string
+var auditProfile string
+var auditLimit int
+var auditOutput string
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Queries the log of issued credentials",
+	Long: `Queries the local audit log. awsx appends a record with the time, config, start URL, account, role, profile, expiry, user and host every time it obtains role credentials.
+--account and --role accept glob patterns such as "*prod*". --account matches the account id or name`,
+	Example:           "awsx audit --since 7d --role \"*Admin*\"\nawsx audit --account 123456789012 --output json",
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var since time.Time
+		if auditSince != "" {
+			var err error
+			if since, err = parseSince(auditSince); err != nil {
+				return err
+			}
+		}
+
+		records, err := internal.ReadAuditLog()
+		if err != nil {
+			return err
+		}
+
+		var matching []internal.AuditRecord
+		for _, record := range records {
+			if record.Time.Before(since) ||
+				(auditConfigName != "" && record.Config != auditConfigName) ||
+				(auditProfile != "" && record.Profile != auditProfile) ||
+				(auditAccount != "" && !globMatch(auditAccount, record.AccountId) && !globMatch(auditAccount, record.AccountName)) ||
+				(auditRole != "" && !globMatch(auditRole, record.Role)) {
+				continue
+			}
+			matching = append(matching, record)
+		}
+
+		// the most recent records are the interesting ones
+		if auditLimit > 0 && len(matching) > auditLimit {
+			matching = matching[len(matching)-auditLimit:]
+		}
+
+		switch auditOutput {
+		case "json":
+			for _, record := range matching {
+				line, err := json.Marshal(record)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(line))
+			}
+		case "table":
+			if len(matching) == 0 {
+				fmt.Println("no audit records found")
+				return nil
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintln(writer, "TIME\tUSER\tHOST\tCOMMAND\tCONFIG\tACCOUNT\tROLE\tPROFILE\tEXPIRES")
+			for _, record := range matching {
+				account := record.AccountId
+				if record.AccountName != "" {
+					account += " (" + record.AccountName + ")"
+				}
+				_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", record.Time.Local().Format(time.DateTime), record.User, record.Hostname, record.Command, record.Config, account, record.Role, record.Profile, record.Expiration.Local().Format(time.DateTime))
+			}
+			return writer.Flush()
+		default:
+			return fmt.Errorf("unknown output format \"%s\". valid values are table and json", auditOutput)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "Only records newer than a duration such as 12h or 7d, or a date such as 2024-01-31")
+	auditCmd.Flags().StringVarP(&auditConfigName, "config", "c", "", "Only records of this config")
+	auditCmd.Flags().StringVar(&auditAccount, "account", "", "Only records of accounts whose id or name matches this pattern")
+	auditCmd.Flags().StringVar(&auditRole, "role", "", "Only records of roles matching this pattern")
+	auditCmd.Flags().StringVarP(&auditProfile, "profile", "p", "", "Only records written to this profile")
+	auditCmd.Flags().IntVarP(&auditLimit, "limit", "n", 0, "Only the most recent records. 0 shows all")
+	auditCmd.Flags().StringVarP(&auditOutput, "output", "o", "table", "Output format: table, or json for one record per line")
+	rootCmd.AddCommand(auditCmd)
+}
+
+// parseSince accepts a duration, with d for days, or a date and returns the point in time it refers to.
+func parseSince(value string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if count, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -count), nil
+		}
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if since, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return since, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid value \"%s\" for --since. expected a duration such as 12h or 7d, or a date such as 2024-01-31", value)
+}
+
+func globMatch(pattern string, value string) bool {
+	matched, err := filepath.Match(pattern, value)
+	return err == nil && matched
+}
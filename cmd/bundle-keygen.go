@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"github.com/vahid-haghighat/awsx/utilities"
+	"os"
+)
+
+var bundleKeygenPrivateKeyPath string
+
+var bundleKeygenCmd = &cobra.Command{
+	Use:               "keygen",
+	Short:             "Creates a key pair for signing bundles",
+	Long:              `Creates an ed25519 key pair for signing bundles. The private key is written to a file, the public key is printed and is what users pass to "awsx init --bundle --public-key"`,
+	Example:           "awsx bundle keygen --private-key-file bundle.key",
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := utilities.AbsolutePath(bundleKeygenPrivateKeyPath)
+		if err != nil {
+			return err
+		}
+
+		publicKey, privateKey, err := internal.GenerateBundleKey()
+		if err != nil {
+			return err
+		}
+
+		// never overwrite an existing key, bundles signed with it could not be updated anymore
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(file, privateKey)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(publicKey)
+		return nil
+	},
+}
+
+func init() {
+	bundleKeygenCmd.Flags().StringVar(&bundleKeygenPrivateKeyPath, "private-key-file", "", "Path to write the private key to")
+	_ = bundleKeygenCmd.MarkFlagRequired("private-key-file")
+	bundleCmd.AddCommand(bundleKeygenCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"github.com/vahid-haghighat/awsx/utilities"
+	"os"
+)
+
+var bundleSignPrivateKeyPath string
+var bundleSignOutputPath string
+
+var bundleSignCmd = &cobra.Command{
+	Use:   "sign <bundle.yaml>",
+	Short: "Signs a bundle for distribution",
+	Long: `Checks a bundle and signs it with a private key from "awsx bundle keygen". The bundle is a YAML file with a name, a serial that grows with every release, configs, and optionally defaults and notes for accounts. browser and command token storages run programs and are rejected in bundles:
+
+  name: platform
+  serial: 1
+  defaults:
+    sso_region: eu-west-1
+  configs:
+    business-unit-a:
+      Id: d-1234567890
+      profiles:
+        default:
+          region: eu-west-1
+  accounts:
+    "123456789012":
+      description: production
+      tags: [pci]`,
+	Example:           "awsx bundle sign platform.yaml --private-key-file bundle.key --output platform.bundle",
+	Args:              cobra.ExactArgs(1),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		payload, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		privateKey, err := os.ReadFile(bundleSignPrivateKeyPath)
+		if err != nil {
+			return err
+		}
+
+		signed, err := internal.SignBundle(payload, string(privateKey))
+		if err != nil {
+			return err
+		}
+
+		if bundleSignOutputPath == "" || bundleSignOutputPath == "-" {
+			fmt.Print(string(signed))
+			return nil
+		}
+
+		bundleSignOutputPath, err = utilities.AbsolutePath(bundleSignOutputPath)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(bundleSignOutputPath, signed, 0644)
+	},
+}
+
+func init() {
+	bundleSignCmd.Flags().StringVar(&bundleSignPrivateKeyPath, "private-key-file", "", "Path of the private key written by \"awsx bundle keygen\"")
+	bundleSignCmd.Flags().StringVarP(&bundleSignOutputPath, "output", "o", "", "Path to write the signed bundle to. Defaults to stdout")
+	_ = bundleSignCmd.MarkFlagRequired("private-key-file")
+	bundleCmd.AddCommand(bundleSignCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"github.com/vahid-haghighat/awsx/utilities"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var bundleStatusCmd = &cobra.Command{
+	Use:               "status",
+	Short:             "Shows the installed bundle",
+	Long:              `Shows the installed bundle, where it is updated from and the configs it manages`,
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFile, err := internal.ReadInternalConfigFile()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		bundle := configFile.Bundle
+		if bundle == nil {
+			fmt.Println("no bundle is installed")
+			return nil
+		}
+
+		configNames := utilities.Keys(bundle.Configs)
+		sort.Strings(configNames)
+
+		fmt.Printf("Bundle:     %s\n", bundle.Name)
+		fmt.Printf("Serial:     %d\n", bundle.Serial)
+		fmt.Printf("Source:     %s\n", bundle.Source)
+		fmt.Printf("Public key: %s\n", bundle.PublicKey)
+		fmt.Printf("Updated:    %s\n", bundle.UpdatedAt.Local().Format(time.DateTime))
+		fmt.Printf("Configs:    %s\n", strings.Join(configNames, ", "))
+		return nil
+	},
+}
+
+func init() {
+	bundleCmd.AddCommand(bundleStatusCmd)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var bundleUpdateCmd = &cobra.Command{
+	Use:               "update",
+	Short:             "Updates the installed bundle",
+	Long:              `Downloads the installed bundle again from where it was installed, verifies it against the pinned public key and merges it when its serial is newer. Configs and profiles added locally are kept`,
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		result, err := internal.UpdateBundle()
+		if err != nil {
+			return err
+		}
+
+		printBundleResult(result)
+		return nil
+	},
+}
+
+func init() {
+	bundleCmd.AddCommand(bundleUpdateCmd)
+}
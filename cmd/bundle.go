@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"strings"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:               "bundle",
+	Short:             "Installs and publishes signed team config bundles",
+	Long:              `A bundle is a signed set of configs, profiles, defaults and account notes a team publishes as a file or URL. It is installed with "awsx init --bundle" and verified against a pinned ed25519 public key on every update. Configs and profiles added locally are kept`,
+	DisableAutoGenTag: true,
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+}
+
+func printBundleResult(result *internal.BundleResult) {
+	for _, warning := range result.Warnings {
+		fmt.Println("Warning:", warning)
+	}
+
+	if result.UpToDate {
+		fmt.Printf("Bundle %s is up to date (serial %d)\n", result.Name, result.Serial)
+		return
+	}
+
+	fmt.Printf("Installed bundle %s serial %d\n", result.Name, result.Serial)
+	for _, change := range []struct {
+		label   string
+		configs []string
+	}{{"Added", result.Added}, {"Updated", result.Updated}, {"Removed", result.Removed}} {
+		if len(change.configs) > 0 {
+			fmt.Printf("%s: %s\n", change.label, strings.Join(change.configs, ", "))
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var configEditCmd = &cobra.Command{
+	Use:               "edit",
+	Short:             "Edits the awsx config file in your editor",
+	Long:              `Opens the awsx config file in $VISUAL or $EDITOR. The file is validated when the editor closes, and reopened with the problems as comments on top until it is valid or emptied. Comments and layout of the file are kept`,
+	Example:           "awsx config edit\nEDITOR=\"code --wait\" awsx config edit",
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		original, err := internal.ReadInternalConfigContent()
+		if err != nil {
+			return err
+		}
+
+		content := original
+		for {
+			edited, err := internal.EditInEditor(content, "awsx-config-*.yaml")
+			if err != nil {
+				return err
+			}
+
+			withoutErrors := internal.RemoveEditorErrors(edited)
+			if len(bytes.TrimSpace(withoutErrors)) == 0 || bytes.Equal(withoutErrors, original) {
+				fmt.Println("Edit cancelled, no changes made")
+				return nil
+			}
+
+			// closing the editor without fixing anything would otherwise reopen it forever
+			if bytes.Equal(edited, content) && !bytes.Equal(content, original) {
+				return errors.New("edit cancelled, the file is still invalid")
+			}
+
+			if err = internal.ValidateInternalConfigContent(withoutErrors); err != nil {
+				content = internal.AddEditorErrors(withoutErrors, err)
+				continue
+			}
+
+			if err = internal.WriteEditedInternalConfig(original, withoutErrors); err != nil {
+				return err
+			}
+
+			fmt.Println("Config saved")
+			return nil
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configEditCmd)
+}
@@ -1,30 +1,49 @@
 package cmd
 
 import (
+	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/vahid-haghighat/awsx/cmd/internal"
 	"github.com/vahid-haghighat/awsx/utilities"
+	"os"
 )
 
 var configExportPath string
+var configExportFormat string
 
 // exportCmd represents the export command
 var exportCmd = &cobra.Command{
-	Use:               "export",
+	Use:               "export [configs...]",
 	Short:             "Exports awsx configs",
-	Long:              `Exports awsx configs`,
+	Long:              `Exports the named awsx configs, or all of them when none is given, as YAML, JSON or TOML. The configs are written to stdout unless a file is given`,
+	Example:           "awsx config export work --file work.json\nawsx config export --format toml > configs.toml",
 	DisableAutoGenTag: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var err error
+		format, err := internal.ConfigFormatFor(configExportFormat, configExportPath)
+		if err != nil {
+			return err
+		}
+
+		content, err := internal.ExportInternalConfig(args, format)
+		if err != nil {
+			return err
+		}
+
+		if configExportPath == "" || configExportPath == "-" {
+			fmt.Print(string(content))
+			return nil
+		}
+
 		configExportPath, err = utilities.AbsolutePath(configExportPath)
 		if err != nil {
 			return err
 		}
-		return internal.ExportInternalConfig(configExportPath)
+		return os.WriteFile(configExportPath, content, 0600)
 	},
 }
 
 func init() {
-	exportCmd.Flags().StringVarP(&configExportPath, "file", "f", "", "Path to save the exported config file")
+	exportCmd.Flags().StringVarP(&configExportPath, "file", "f", "", "Path to save the exported config file. Defaults to stdout")
+	exportCmd.Flags().StringVar(&configExportFormat, "format", "", "Format of the exported configs: yaml, json or toml. Defaults to the file extension, or yaml")
 	configCmd.AddCommand(exportCmd)
 }
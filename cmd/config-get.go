@@ -1,24 +1,48 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/vahid-haghighat/awsx/cmd/internal"
 	"gopkg.in/yaml.v3"
+	"os"
 )
 
+var configGetResolved bool
+
 var getConfigCmd = &cobra.Command{
 	Use:               "get",
 	Short:             "Prints awsx's Configuration",
-	Long:              `Prints awsx's Configuration`,
+	Long:              `Prints awsx's Configuration as it is stored. With --resolved, prints every config with the values it inherits filled in`,
 	DisableAutoGenTag: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		config, err := internal.ReadInternalConfig()
-		if err != nil {
-			fmt.Println("no configuration found")
-			return nil
+		var toPrint any
+		if configGetResolved {
+			configs, err := internal.ReadInternalConfig()
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Println("no configuration found")
+				return nil
+			} else if err != nil {
+				return err
+			}
+			toPrint = configs
+		} else {
+			configFile, err := internal.ReadInternalConfigFile()
+			if err != nil {
+				fmt.Println("no configuration found")
+				return nil
+			}
+			toPrint = configFile.Configs
+			if configFile.Defaults != nil {
+				toPrint = struct {
+					Defaults *internal.Config            `yaml:"defaults"`
+					Configs  map[string]*internal.Config `yaml:"configs"`
+				}{configFile.Defaults, configFile.Configs}
+			}
 		}
-		marshal, err := yaml.Marshal(config)
+
+		marshal, err := yaml.Marshal(toPrint)
 		if err != nil {
 			return fmt.Errorf("internal error")
 		}
@@ -28,5 +52,6 @@ var getConfigCmd = &cobra.Command{
 }
 
 func init() {
+	getConfigCmd.Flags().BoolVar(&configGetResolved, "resolved", false, "Prints the configs with their inherited values")
 	configCmd.AddCommand(getConfigCmd)
 }
@@ -1,30 +1,282 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"github.com/vahid-haghighat/awsx/cmd/internal"
 	"github.com/vahid-haghighat/awsx/utilities"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 )
 
 var configImportPath string
+var configImportFromAwsConfig bool
+var configImportFrom string
+var configImportFormat string
+var configImportMerge bool
+var configImportOverwrite bool
+var configImportSkipExisting bool
+var configImportDryRun bool
+var configImportYes bool
 
 // importCmd represents the import command
 var importCmd = &cobra.Command{
-	Use:               "import",
-	Short:             "Imports awsx configs",
-	Long:              `Imports awsx configs`,
+	Use:   "import",
+	Short: "Imports awsx configs",
+	Long: `Imports awsx configs from a file exported by awsx, or from the SSO profiles of the AWS config file as written by the AWS CLI, aws-vault, granted or aws-sso-util.
+Existing configs are kept. Configs that already exist are merged, overwritten or skipped as chosen by the flags, or interactively otherwise. The changes are shown before anything is written`,
+	Example:           "awsx config import --file configs.yaml --merge\ncat team.json | awsx config import --file - --skip-existing --yes\nawsx config import --from-aws-config\nawsx config import --from granted",
 	DisableAutoGenTag: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if configImportFromAwsConfig {
+			configImportFrom = string(internal.ImportSourceAwsConfig)
+		}
+
+		var imported map[string]*internal.Config
 		var err error
-		configImportPath, err = utilities.AbsolutePath(configImportPath)
+		if configImportFrom != "" {
+			imported, err = importFromSource(internal.ImportSource(configImportFrom))
+		} else {
+			imported, err = importFromFile(configImportPath)
+		}
+		if err != nil {
+			return err
+		}
+
+		// the configs are written back as they are stored, without the values they inherit
+		configFile, err := internal.ReadInternalConfigFile()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		existing := configFile.Configs
+
+		prompter, err := internal.DefaultSelector()
 		if err != nil {
 			return err
 		}
-		return internal.ImportInternalConfig(configImportPath)
+		resolutions := make(map[string]internal.ConflictResolution)
+		if resolution, ok := importConflictResolution(); ok {
+			for _, configName := range internal.ImportConflicts(existing, imported) {
+				resolutions[configName] = resolution
+			}
+		} else if configImportPath != "-" {
+			resolutions, err = resolveImportConflicts(prompter, existing, imported)
+			if err != nil {
+				return err
+			}
+		}
+
+		// merging may change the existing configs in place, the diff needs a copy of their current state
+		before, err := copyConfigs(existing)
+		if err != nil {
+			return err
+		}
+
+		merged, err := internal.MergeImportedConfigs(configFile, imported, resolutions)
+		if err != nil {
+			return err
+		}
+
+		diff, err := internal.DiffInternalConfigs(before, merged)
+		if err != nil {
+			return err
+		}
+
+		if diff == "" {
+			fmt.Println("Nothing to import, the configs are up to date")
+			return nil
+		}
+		fmt.Print(diff)
+
+		if configImportDryRun {
+			return nil
+		}
+
+		if !configImportYes {
+			if configImportPath == "-" {
+				return errors.New("configs read from stdin are only imported with --yes")
+			}
+
+			index, _, err := prompter.Select("Import these changes?", []string{"Yes", "No"}, nil)
+			if err != nil {
+				return err
+			}
+			if index != 0 {
+				return errors.New("import cancelled")
+			}
+		}
+
+		return internal.WriteInternalConfig(merged)
 	},
 }
 
 func init() {
+	var sources []string
+	for _, source := range internal.ImportSources {
+		sources = append(sources, string(source))
+	}
+
+	importCmd.Flags().StringVarP(&configImportPath, "file", "f", "", "Path of the config file to import, or - to read from stdin")
+	importCmd.Flags().BoolVar(&configImportFromAwsConfig, "from-aws-config", false, "Create configs from the [sso-session] blocks and SSO profiles of the AWS config file")
+	importCmd.Flags().StringVar(&configImportFrom, "from", "", "Create configs from the SSO profiles of another tool: "+strings.Join(sources, ", "))
+	importCmd.Flags().StringVar(&configImportFormat, "format", "", "Format of the imported file: yaml, json or toml. Defaults to the file extension, or yaml")
+	importCmd.Flags().BoolVar(&configImportMerge, "merge", false, "Add the profiles of configs that already exist to them")
+	importCmd.Flags().BoolVar(&configImportOverwrite, "overwrite", false, "Replace configs that already exist")
+	importCmd.Flags().BoolVar(&configImportSkipExisting, "skip-existing", false, "Keep configs that already exist unchanged")
+	importCmd.Flags().BoolVar(&configImportDryRun, "dry-run", false, "Only show the changes")
+	importCmd.Flags().BoolVarP(&configImportYes, "yes", "y", false, "Import without asking for confirmation")
+	importCmd.MarkFlagsMutuallyExclusive("file", "from-aws-config", "from")
+	importCmd.MarkFlagsMutuallyExclusive("merge", "overwrite", "skip-existing")
+	importCmd.MarkFlagsOneRequired("file", "from-aws-config", "from")
 	configCmd.AddCommand(importCmd)
 }
+
+func importConflictResolution() (internal.ConflictResolution, bool) {
+	switch {
+	case configImportMerge:
+		return internal.ConflictResolutionMerge, true
+	case configImportOverwrite:
+		return internal.ConflictResolutionOverwrite, true
+	case configImportSkipExisting:
+		return internal.ConflictResolutionSkip, true
+	}
+
+	return "", false
+}
+
+func importFromFile(importPath string) (map[string]*internal.Config, error) {
+	format, err := internal.ConfigFormatFor(configImportFormat, importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	if importPath == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		importPath, err = utilities.AbsolutePath(importPath)
+		if err != nil {
+			return nil, err
+		}
+		content, err = os.ReadFile(importPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return internal.ImportInternalConfig(content, format)
+}
+
+func importFromSource(source internal.ImportSource) (map[string]*internal.Config, error) {
+	imported, warnings, err := internal.ReadImportSource(source)
+	for _, warning := range warnings {
+		fmt.Println("Warning:", warning)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := internal.ReadInternalConfig()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err = printImportPreview(existing, imported); err != nil {
+		return nil, err
+	}
+
+	return imported, nil
+}
+
+func copyConfigs(configs map[string]*internal.Config) (map[string]*internal.Config, error) {
+	content, err := yaml.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := make(map[string]*internal.Config)
+	return copied, yaml.Unmarshal(content, &copied)
+}
+
+func printImportPreview(existing map[string]*internal.Config, imported map[string]*internal.Config) error {
+	configNames := utilities.Keys(imported)
+	sort.Strings(configNames)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "NAME\tSTART URL\tSSO REGION\tPROFILES\tSTATUS")
+	for _, configName := range configNames {
+		config := imported[configName]
+
+		profileNames := utilities.Keys(config.Profiles)
+		sort.Strings(profileNames)
+
+		var profiles []string
+		for _, profileName := range profileNames {
+			profile := config.Profiles[profileName]
+			description := profileName + " (" + profile.Region
+			if profile.Pinned() {
+				description += ", " + profile.AccountId + "/" + profile.Role
+			}
+			profiles = append(profiles, description+")")
+		}
+
+		status := "new"
+		if _, exists := existing[configName]; exists {
+			status = "exists"
+		}
+
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", configName, config.GetStartUrl(), config.SsoRegion, strings.Join(profiles, ", "), status)
+	}
+
+	return writer.Flush()
+}
+
+// resolveImportConflicts asks how to handle every imported config whose name is taken. Renamed configs are moved to
+// their new name in imported.
+func resolveImportConflicts(prompter internal.Prompt, existing map[string]*internal.Config, imported map[string]*internal.Config) (map[string]internal.ConflictResolution, error) {
+	resolutions := make(map[string]internal.ConflictResolution)
+	options := []string{
+		"Merge its profiles into the existing config",
+		"Overwrite the existing config",
+		"Import it under a different name",
+		"Skip it",
+	}
+
+	for _, configName := range internal.ImportConflicts(existing, imported) {
+		index, _, err := prompter.Select(fmt.Sprintf("Config %s already exists", configName), options, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		switch index {
+		case 0:
+			resolutions[configName] = internal.ConflictResolutionMerge
+		case 1:
+			resolutions[configName] = internal.ConflictResolutionOverwrite
+		case 2:
+			newName, err := prompter.Prompt("New config name", configName+"-imported")
+			if err != nil {
+				return nil, err
+			}
+
+			_, existsAlready := existing[newName]
+			_, importedAlready := imported[newName]
+			if newName == "" || existsAlready || importedAlready {
+				return nil, fmt.Errorf("config name \"%s\" is empty or already taken", newName)
+			}
+
+			imported[newName] = imported[configName]
+			delete(imported, configName)
+		default:
+			resolutions[configName] = internal.ConflictResolutionSkip
+		}
+	}
+
+	return resolutions, nil
+}
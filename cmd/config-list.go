@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"github.com/vahid-haghighat/awsx/utilities"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var configListOutput string
+
+type configListProfile struct {
+	Name   string `json:"name"`
+	Region string `json:"region"`
+}
+
+type configListEntry struct {
+	Name        string              `json:"name"`
+	StartUrl    string              `json:"start_url"`
+	SsoRegion   string              `json:"sso_region"`
+	HistorySize int                 `json:"history_size"`
+	Profiles    []configListProfile `json:"profiles"`
+}
+
+var configListCmd = &cobra.Command{
+	Use:               "list",
+	Short:             "Lists awsx configs",
+	Long:              `Lists awsx configs as a table or as JSON`,
+	Example:           "awsx config list --output json",
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configs, err := internal.ReadInternalConfig()
+		if err != nil {
+			configs = make(map[string]*internal.Config)
+		}
+
+		configNames := utilities.Keys(configs)
+		sort.Strings(configNames)
+
+		entries := make([]configListEntry, 0, len(configNames))
+		for _, configName := range configNames {
+			config := configs[configName]
+			entry := configListEntry{
+				Name:        configName,
+				StartUrl:    config.GetStartUrl(),
+				SsoRegion:   config.SsoRegion,
+				HistorySize: config.LastUsedAccountsCount,
+				Profiles:    []configListProfile{},
+			}
+
+			profileNames := utilities.Keys(config.Profiles)
+			sort.Strings(profileNames)
+			for _, profileName := range profileNames {
+				entry.Profiles = append(entry.Profiles, configListProfile{Name: profileName, Region: config.Profiles[profileName].Region})
+			}
+
+			entries = append(entries, entry)
+		}
+
+		switch configListOutput {
+		case "json":
+			content, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(content))
+		case "table":
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintln(writer, "NAME\tSTART URL\tSSO REGION\tHISTORY SIZE\tPROFILES")
+			for _, entry := range entries {
+				var profiles []string
+				for _, profile := range entry.Profiles {
+					profiles = append(profiles, profile.Name+" ("+profile.Region+")")
+				}
+				_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%s\n", entry.Name, entry.StartUrl, entry.SsoRegion, entry.HistorySize, strings.Join(profiles, ", "))
+			}
+			return writer.Flush()
+		default:
+			return fmt.Errorf("unknown output format \"%s\". valid values are table and json", configListOutput)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	configListCmd.Flags().StringVarP(&configListOutput, "output", "o", "table", "Output format: table or json")
+	configCmd.AddCommand(configListCmd)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var configProfileAddRegion string
+var configProfileAddAccount string
+var configProfileAddRole string
+var configProfileAddOutput string
+var configProfileAddSettings []string
+
+var configProfileAddCmd = &cobra.Command{
+	Use:               "add <config> <profile>",
+	Short:             "Adds a profile to a config",
+	Long:              `Adds a profile to a config`,
+	Example:           "awsx config profile add work dev --region eu-west-1\nawsx config profile add work ci --region eu-west-1 --output text --setting cli_pager= --setting s3.max_concurrent_requests=20",
+	Args:              cobra.ExactArgs(2),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if configProfileAddRegion == "" {
+			return errors.New("region cannot be empty. please pass --region")
+		}
+
+		settings, err := parseProfileSettings(configProfileAddSettings)
+		if err != nil {
+			return err
+		}
+		if len(settings) == 0 {
+			settings = nil
+		}
+
+		return internal.UpdateInternalConfig(args[0], false, func(config *internal.Config) error {
+			if _, exists := config.Profiles[args[1]]; exists {
+				return fmt.Errorf("profile \"%s\" already exists in config \"%s\"", args[1], args[0])
+			}
+
+			config.Profiles[args[1]] = &internal.Profile{
+				Region:    configProfileAddRegion,
+				AccountId: configProfileAddAccount,
+				Role:      configProfileAddRole,
+				Output:    configProfileAddOutput,
+				Settings:  settings,
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	configProfileAddCmd.Flags().StringVarP(&configProfileAddRegion, "region", "r", "", "Region written with the profile's credentials")
+	configProfileAddCmd.Flags().StringVar(&configProfileAddAccount, "account", "", "Account id to always use for this profile instead of prompting. Requires --role")
+	configProfileAddCmd.Flags().StringVar(&configProfileAddRole, "role", "", "Role to always use for this profile instead of prompting. Requires --account")
+	configProfileAddCmd.Flags().StringVarP(&configProfileAddOutput, "output", "o", "", "Output format of the AWS CLI: json, yaml, yaml-stream, text or table. Empty writes json")
+	configProfileAddCmd.Flags().StringArrayVar(&configProfileAddSettings, "setting", nil, "Extra key=value written with the profile's credentials, for example retry_mode=adaptive. Can be repeated")
+	configProfileCmd.AddCommand(configProfileAddCmd)
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var configProfileRemoveCmd = &cobra.Command{
+	Use:               "remove <config> <profile>",
+	Short:             "Removes a profile from a config",
+	Long:              `Removes a profile from a config`,
+	Args:              cobra.ExactArgs(2),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.UpdateInternalConfig(args[0], false, func(config *internal.Config) error {
+			if _, exists := config.Profiles[args[1]]; !exists {
+				return fmt.Errorf("profile \"%s\" does not exist in config \"%s\"", args[1], args[0])
+			}
+
+			delete(config.Profiles, args[1])
+			return nil
+		})
+	},
+}
+
+func init() {
+	configProfileCmd.AddCommand(configProfileRemoveCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var configProfileSetRegionCmd = &cobra.Command{
+	Use:               "set-region <config> <profile> <region>",
+	Short:             "Changes the region of a profile",
+	Long:              `Changes the region of a profile`,
+	Example:           "awsx config profile set-region work dev eu-central-1",
+	Args:              cobra.ExactArgs(3),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[2] == "" {
+			return errors.New("region cannot be empty")
+		}
+
+		return internal.UpdateInternalConfig(args[0], false, func(config *internal.Config) error {
+			profile, exists := config.Profiles[args[1]]
+			if !exists {
+				return fmt.Errorf("profile \"%s\" does not exist in config \"%s\"", args[1], args[0])
+			}
+
+			profile.Region = args[2]
+			return nil
+		})
+	},
+}
+
+func init() {
+	configProfileCmd.AddCommand(configProfileSetRegionCmd)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"strings"
+)
+
+var configProfileSetOutput string
+var configProfileSetUnset []string
+
+var configProfileSetCmd = &cobra.Command{
+	Use:               "set <config> <profile> [key=value...]",
+	Short:             "Changes the output format and the extra settings of a profile",
+	Long:              `Changes the output format and the extra settings of a profile. They are written to the section of the profile in the AWS config file with every refresh of the profile. Dotted keys such as s3.max_concurrent_requests are written as nested values`,
+	Example:           "awsx config profile set work dev cli_pager= retry_mode=adaptive s3.max_concurrent_requests=20 --output table\nawsx config profile set work dev --unset retry_mode",
+	Args:              cobra.MinimumNArgs(2),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := parseProfileSettings(args[2:])
+		if err != nil {
+			return err
+		}
+
+		if len(settings) == 0 && len(configProfileSetUnset) == 0 && !cmd.Flags().Changed("output") {
+			return errors.New("nothing to change. pass key=value settings, --unset or --output")
+		}
+
+		return internal.UpdateInternalConfig(args[0], false, func(config *internal.Config) error {
+			profile, exists := config.Profiles[args[1]]
+			if !exists {
+				return fmt.Errorf("profile \"%s\" does not exist in config \"%s\"", args[1], args[0])
+			}
+
+			if cmd.Flags().Changed("output") {
+				profile.Output = configProfileSetOutput
+			}
+
+			for _, key := range configProfileSetUnset {
+				if _, ok := profile.Settings[key]; !ok {
+					return fmt.Errorf("setting \"%s\" is not set for profile \"%s\"", key, args[1])
+				}
+				delete(profile.Settings, key)
+			}
+
+			for key, value := range settings {
+				if profile.Settings == nil {
+					profile.Settings = make(map[string]string)
+				}
+				profile.Settings[key] = value
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	configProfileSetCmd.Flags().StringVarP(&configProfileSetOutput, "output", "o", "", "Output format of the AWS CLI: json, yaml, yaml-stream, text or table. Empty writes json")
+	configProfileSetCmd.Flags().StringArrayVar(&configProfileSetUnset, "unset", nil, "Removes a setting. Can be repeated")
+	configProfileCmd.AddCommand(configProfileSetCmd)
+}
+
+// parseProfileSettings parses key=value arguments. An empty value is allowed, for example to disable cli_pager.
+func parseProfileSettings(arguments []string) (map[string]string, error) {
+	settings := make(map[string]string)
+	for _, argument := range arguments {
+		key, value, ok := strings.Cut(argument, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid setting \"%s\". expected key=value", argument)
+		}
+
+		key = strings.TrimSpace(key)
+		if err := internal.ValidateProfileSetting(key, value); err != nil {
+			return nil, err
+		}
+		settings[key] = strings.TrimSpace(value)
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var configProfileCmd = &cobra.Command{
+	Use:               "profile",
+	Short:             "Manages the profiles of a config without prompting",
+	Long:              `Manages the profiles of a config without prompting`,
+	DisableAutoGenTag: true,
+}
+
+func init() {
+	configCmd.AddCommand(configProfileCmd)
+}
@@ -11,9 +11,13 @@ var configRemoveCmd = &cobra.Command{
 	Long:              `Removes awsx's Configuration`,
 	DisableAutoGenTag: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		configNames := []string{"default"}
-		if len(args) > 0 {
-			configNames = args
+		configNames := args
+		if len(configNames) == 0 {
+			context, err := internal.CurrentConfigContext()
+			if err != nil {
+				return err
+			}
+			configNames = []string{context.Config}
 		}
 		return internal.RemoveInternalConfig(configNames)
 	},
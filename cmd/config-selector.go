@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var configSelectorCmd = &cobra.Command{
+	Use:   "selector [auto|promptui|fzf|plain|command:<program>]",
+	Short: "Selects how awsx shows lists to pick from",
+	Long: `Selects how awsx shows lists to pick from and asks for values, for the whole installation. AWSX_SELECTOR overrides the selection.
+
+  auto               plain for dumb terminals and pipes, fzf when it is on the PATH, promptui otherwise
+  promptui           interactive list with fuzzy search
+  fzf                fzf, which also works under tmux and in most editor shells
+  plain              numbered list read line by line from stdin
+  command:<program>  external program such as "rofi -dmenu". It reads the items from stdin and prints the chosen one.
+                     AWSX_SELECTOR_MODE (select, prompt or secret), AWSX_SELECTOR_LABEL and AWSX_SELECTOR_DEFAULT describe the question
+
+Without arguments the current selection is printed.`,
+	Example:           "awsx config selector fzf\nawsx config selector \"command:rofi -dmenu -i\"\nAWSX_SELECTOR=plain awsx select",
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			return internal.SetSelector(args[0])
+		}
+
+		fmt.Println(internal.SelectorName())
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSelectorCmd)
+}
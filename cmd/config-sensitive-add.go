@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"slices"
+)
+
+var configSensitiveAddCmd = &cobra.Command{
+	Use:   "add <config>",
+	Short: "Marks accounts or roles of a config as sensitive",
+	Long: `Marks accounts or roles of a config as sensitive. A role is sensitive when it matches every flag of a rule.
+--account-name and --role accept glob patterns and ignore case`,
+	Example:           "awsx config sensitive add work --account-name \"*prod*\" --role \"*Admin*\"\nawsx config sensitive add work --account-id 123456789012",
+	Args:              cobra.ExactArgs(1),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rule := internal.SensitiveRule{
+			AccountId:   configSensitiveAccountId,
+			AccountName: configSensitiveAccountName,
+			Role:        configSensitiveRole,
+		}
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+
+		return internal.UpdateInternalConfig(args[0], false, func(config *internal.Config) error {
+			if slices.Contains(config.Sensitive, rule) {
+				return fmt.Errorf("config \"%s\" already has the sensitive rule \"%s\"", args[0], rule)
+			}
+
+			config.Sensitive = append(config.Sensitive, rule)
+			return nil
+		})
+	},
+}
+
+func init() {
+	addSensitiveRuleFlags(configSensitiveAddCmd)
+	configSensitiveCmd.AddCommand(configSensitiveAddCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"slices"
+)
+
+var configSensitiveRemoveCmd = &cobra.Command{
+	Use:               "remove <config>",
+	Short:             "Removes a sensitive rule from a config",
+	Long:              `Removes the sensitive rule with exactly the given flags from a config. Rules inherited from another config are removed there`,
+	Example:           "awsx config sensitive remove work --account-name \"*prod*\" --role \"*Admin*\"",
+	Args:              cobra.ExactArgs(1),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rule := internal.SensitiveRule{
+			AccountId:   configSensitiveAccountId,
+			AccountName: configSensitiveAccountName,
+			Role:        configSensitiveRole,
+		}
+
+		return internal.UpdateInternalConfig(args[0], false, func(config *internal.Config) error {
+			index := slices.Index(config.Sensitive, rule)
+			if index < 0 {
+				return fmt.Errorf("config \"%s\" does not have the sensitive rule \"%s\"", args[0], rule)
+			}
+
+			config.Sensitive = slices.Delete(config.Sensitive, index, index+1)
+			return nil
+		})
+	},
+}
+
+func init() {
+	addSensitiveRuleFlags(configSensitiveRemoveCmd)
+	configSensitiveCmd.AddCommand(configSensitiveRemoveCmd)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var configSensitiveAccountId string
+var configSensitiveAccountName string
+var configSensitiveRole string
+
+var configSensitiveCmd = &cobra.Command{
+	Use:   "sensitive",
+	Short: "Manages the accounts and roles that need a break-glass confirmation",
+	Long: `Manages the accounts and roles that need a break-glass confirmation. Before awsx issues credentials for a sensitive role it shows a banner and asks for the account name and a justification, which is recorded in the audit log.
+Non-interactive commands refuse sensitive roles unless a justification is given with --break-glass`,
+	DisableAutoGenTag: true,
+}
+
+func init() {
+	configCmd.AddCommand(configSensitiveCmd)
+}
+
+func addSensitiveRuleFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&configSensitiveAccountId, "account-id", "", "Id of the sensitive account")
+	cmd.Flags().StringVar(&configSensitiveAccountName, "account-name", "", "Pattern matching the names of sensitive accounts, such as \"*prod*\"")
+	cmd.Flags().StringVar(&configSensitiveRole, "role", "", "Pattern matching the names of sensitive roles, such as \"*Admin*\"")
+}
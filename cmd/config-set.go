@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var configSetStartUrl string
+var configSetSsoRegion string
+var configSetHistorySize int
+var configSetExtends string
+var configSetBrowser string
+
+var configSetCmd = &cobra.Command{
+	Use:               "set <config>",
+	Short:             "Creates or updates a config without prompting",
+	Long:              `Creates or updates a config without prompting. Only the given flags are changed. Values that are not set are inherited from the extended config, or from the defaults block of the config file`,
+	Example:           "awsx config set work --start-url https://d-1234567890.awsapps.com/start --sso-region us-east-1 --history-size 5\nawsx config set staging --extends work --browser none",
+	Args:              cobra.ExactArgs(1),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.UpdateInternalConfig(args[0], true, func(config *internal.Config) error {
+			if cmd.Flags().Changed("start-url") {
+				id, err := internal.StartUrlId(configSetStartUrl)
+				if err != nil {
+					return err
+				}
+				config.Id = id
+			}
+
+			if cmd.Flags().Changed("sso-region") {
+				config.SsoRegion = configSetSsoRegion
+			}
+
+			if cmd.Flags().Changed("history-size") {
+				config.LastUsedAccountsCount = configSetHistorySize
+			}
+
+			if cmd.Flags().Changed("extends") {
+				config.Extends = configSetExtends
+			}
+
+			if cmd.Flags().Changed("browser") {
+				config.Browser = configSetBrowser
+			}
+
+			return nil
+		})
+	},
+}
+
+func init() {
+	configSetCmd.Flags().StringVar(&configSetStartUrl, "start-url", "", "Start URL of the AWS access portal, or just its id")
+	configSetCmd.Flags().StringVar(&configSetSsoRegion, "sso-region", "", "Region of the IAM Identity Center instance")
+	configSetCmd.Flags().IntVar(&configSetHistorySize, "history-size", 1, "Number of recently used roles offered by the refresh command")
+	configSetCmd.Flags().StringVar(&configSetExtends, "extends", "", "Name of the config to inherit unset values and profiles from. Empty inherits from the defaults block")
+	configSetCmd.Flags().StringVar(&configSetBrowser, "browser", "", "Command that opens the login page, or \"none\" to only print its URL")
+	configCmd.AddCommand(configSetCmd)
+}
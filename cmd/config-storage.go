@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"github.com/vahid-haghighat/awsx/utilities"
+	"sort"
+)
+
+var storageConfigName string
+
+var configStorageCmd = &cobra.Command{
+	Use:   "storage [plaintext|encrypted|secret-service|command:<helper>]",
+	Short: "Selects where the SSO access tokens are stored",
+	Long: `Selects where the SSO access tokens are stored, for the whole installation or for a single config with --config.
+Existing tokens are migrated to the new storage.
+
+  plaintext         YAML file in the awsx cache directory
+  encrypted         file encrypted with a passphrase, read from AWSX_CACHE_PASSPHRASE or prompted for
+  secret-service    freedesktop Secret Service over D-Bus, for example GNOME Keyring or KWallet
+  command:<helper>  external helper called as "<helper> get|store|erase <key>", store reads the value from stdin
+
+Without arguments the current selection is printed.`,
+	Example:           "awsx config storage encrypted\nawsx config storage --config work \"command:awsx-pass-helper\"",
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			return internal.SetTokenStorage(storageConfigName, args[0])
+		}
+
+		if storageConfigName != "" {
+			fmt.Printf("%s\t%s\n", storageConfigName, internal.TokenStorageName(storageConfigName))
+			return nil
+		}
+
+		configs, err := internal.ReadInternalConfig()
+		if err != nil {
+			fmt.Println("no configuration found")
+			return nil
+		}
+
+		configNames := utilities.Keys(configs)
+		sort.Strings(configNames)
+		for _, configName := range configNames {
+			fmt.Printf("%s\t%s\n", configName, internal.TokenStorageName(configName))
+		}
+		return nil
+	},
+}
+
+func init() {
+	configStorageCmd.Flags().StringVarP(&storageConfigName, "config", "c", "", "Name of the config to select the storage for. Applies to the whole installation when empty")
+	configCmd.AddCommand(configStorageCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:               "validate [configs...]",
+	Short:             "Checks configs for invalid regions, start URLs and profile names",
+	Long:              `Checks configs for invalid regions, start URLs and profile names. All configs are checked when none is given, and every problem found is reported`,
+	Example:           "awsx config validate work personal",
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configs, err := internal.ReadInternalConfig()
+		if err != nil {
+			return err
+		}
+
+		if len(args) > 0 {
+			selected := make(map[string]*internal.Config)
+			for _, configName := range args {
+				config, ok := configs[configName]
+				if !ok {
+					return fmt.Errorf("config \"%s\" does not exist", configName)
+				}
+				selected[configName] = config
+			}
+			configs = selected
+		}
+
+		if err = internal.ValidateConfigs(configs); err != nil {
+			return err
+		}
+
+		fmt.Printf("%d config(s) are valid\n", len(configs))
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
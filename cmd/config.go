@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/vahid-haghighat/awsx/cmd/internal"
 	"github.com/vahid-haghighat/awsx/utilities"
+	"os"
 	"sort"
 	"strconv"
 )
@@ -16,9 +18,13 @@ var configCmd = &cobra.Command{
 	Example:           "awsx config my-sso-config",
 	DisableAutoGenTag: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		configNames := []string{"default"}
-		if len(args) > 0 {
-			configNames = args
+		configNames := args
+		if len(configNames) == 0 {
+			context, err := internal.CurrentConfigContext()
+			if err != nil {
+				return err
+			}
+			configNames = []string{context.Config}
 		}
 		return configArgs(configNames)
 	},
@@ -29,11 +35,16 @@ func init() {
 }
 
 func configArgs(configNames []string) error {
-	configs, _ := internal.ReadInternalConfig()
-	if configs == nil || len(configs) == 0 {
-		configs = make(map[string]*internal.Config)
+	// a file that cannot be read must not be replaced by the answers to the wizard
+	configFile, err := internal.ReadInternalConfigFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	configs := configFile.Configs
+	prompter, err := internal.DefaultSelector()
+	if err != nil {
+		return err
 	}
-	prompter := internal.Prompter{}
 
 	for _, configName := range configNames {
 		if configName == "" {
@@ -43,45 +54,99 @@ func configArgs(configNames []string) error {
 		config, ok := configs[configName]
 		if !ok {
 			config = &internal.Config{
-				Id:                    "",
-				SsoRegion:             "",
-				Profiles:              make(map[string]*internal.Profile),
-				LastUsedAccountsCount: 1,
+				Id:        "",
+				SsoRegion: "",
+				Profiles:  make(map[string]*internal.Profile),
+			}
+
+			if len(configs) > 0 {
+				existingNames := utilities.Keys(configs)
+				sort.Strings(existingNames)
+				index, _, err := prompter.Select("Config to extend", append([]string{"None"}, existingNames...), nil)
+				if err != nil {
+					fmt.Printf("Failed to prompt for the config %s extends\n", configName)
+					continue
+				}
+				if index > 0 {
+					config.Extends = existingNames[index-1]
+				}
 			}
 		}
 		config.Complete = false
 
-		var err error
-		config.Id, err = prompter.Prompt("Start URL Id", config.Id)
+		// values equal to the inherited ones are left empty, so the config keeps following its parent
+		parent, err := configFile.Parent(configName, config)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if parent == nil {
+			parent = &internal.Config{}
+		}
+
+		startUrlId, err := prompter.Prompt("Start URL Id", ownOrInherited(config.Id, parent.Id))
 		if err != nil {
 			fmt.Printf("Failed to prompt for start URL Id for %s\n", configName)
 			continue
 		}
 
-		config.SsoRegion, err = prompter.Prompt("SSO Region", config.SsoRegion)
+		startUrlId, err = internal.StartUrlId(startUrlId)
+		if err == nil {
+			err = internal.ValidateStartUrlId(startUrlId)
+		}
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		config.Id = ownValue(startUrlId, config.Id, parent.Id)
+
+		ssoRegion, err := prompter.Prompt("SSO Region", ownOrInherited(config.SsoRegion, parent.SsoRegion))
 		if err != nil {
 			fmt.Printf("Failed to prompt for sso region for %s\n", configName)
 			continue
 		}
 
-		if config.SsoRegion == "" {
-			fmt.Println("SSO Region cannot be empty")
+		if err = internal.ValidateRegion(ssoRegion); err != nil {
+			fmt.Printf("Invalid SSO region: %s\n", err)
 			continue
 		}
+		config.SsoRegion = ownValue(ssoRegion, config.SsoRegion, parent.SsoRegion)
 
-		lastUsedAccountCountString, err := prompter.Prompt("Profile count to cache for refresh command", "1")
+		inheritedCount := ""
+		if parent.LastUsedAccountsCount > 0 {
+			inheritedCount = strconv.Itoa(parent.LastUsedAccountsCount)
+		}
+		ownCount := ""
+		if config.LastUsedAccountsCount > 0 {
+			ownCount = strconv.Itoa(config.LastUsedAccountsCount)
+		}
+		defaultCount := ownOrInherited(ownCount, inheritedCount)
+		if defaultCount == "" {
+			defaultCount = "1"
+		}
+
+		lastUsedAccountCountString, err := prompter.Prompt("Profile count to cache for refresh command", defaultCount)
 		if err != nil {
 			fmt.Printf("Failed to prompt for cached profile counts for %s\n", configName)
 			continue
 		}
 
-		config.LastUsedAccountsCount, err = strconv.Atoi(lastUsedAccountCountString)
+		lastUsedAccountsCount, err := strconv.Atoi(lastUsedAccountCountString)
 		if err != nil {
 			fmt.Printf("Invalid number for %s cached accounts count\n", configName)
 			continue
 		}
+		config.LastUsedAccountsCount = lastUsedAccountsCount
+		if ownCount == "" && lastUsedAccountCountString == inheritedCount {
+			config.LastUsedAccountsCount = 0
+		}
 
 		profileNames := utilities.Keys(config.Profiles)
+		for profileName := range parent.Profiles {
+			if _, own := config.Profiles[profileName]; !own {
+				profileNames = append(profileNames, profileName)
+			}
+		}
 		sort.SliceStable(profileNames, func(i, j int) bool {
 			return profileNames[i] < profileNames[j]
 		})
@@ -99,8 +164,8 @@ func configArgs(configNames []string) error {
 				fmt.Printf("Failed to prompt for %s config argument: %s\n", configName, err)
 				break
 			}
-			if profileName == "" {
-				fmt.Println("Profile name cannot be empty")
+			if err = internal.ValidateProfileName(profileName); err != nil {
+				fmt.Println(err)
 				break
 			}
 
@@ -108,30 +173,33 @@ func configArgs(configNames []string) error {
 				profileNames = profileNames[1:]
 			}
 
-			defaultRegion, found := config.Profiles[profileName]
+			inheritedProfile, inherited := parent.Profiles[profileName]
+			if !inherited {
+				inheritedProfile = &internal.Profile{}
+			}
+			profile, found := config.Profiles[profileName]
 			if !found {
-				defaultRegion = &internal.Profile{
-					Region: "",
-				}
+				profile = &internal.Profile{}
 			}
 
-			region, err = prompter.Prompt("Region", defaultRegion.Region)
+			region, err = prompter.Prompt("Region", ownOrInherited(profile.Region, inheritedProfile.Region))
 			if err != nil {
 				fmt.Printf("Failed to prompt for region for %s: %s\n", configName, err)
 				break
 			}
-			if region == "" {
-				fmt.Println("Region name cannot be empty")
+			if err = internal.ValidateRegion(region); err != nil {
+				fmt.Println(err)
 				break
 			}
 
-			config.Profiles[profileName] = &internal.Profile{
-				Region: region,
+			profile.Region = ownValue(region, profile.Region, inheritedProfile.Region)
+			if found || profile.Region != "" || !inherited {
+				config.Profiles[profileName] = profile
 			}
 			profilesConfigured++
 
-			index, _, _ := prompter.Select("Do you wish to add another profile to this config?", []string{"Yes", "No"}, nil)
-			if index != 0 {
+			index, _, err := prompter.Select("Do you wish to add another profile to this config?", []string{"Yes", "No"}, nil)
+			if err != nil || index != 0 {
 				break
 			}
 		}
@@ -140,9 +208,37 @@ func configArgs(configNames []string) error {
 			continue
 		}
 
+		resolved, err := configFile.ResolveConfig(configName, config)
+		if err == nil {
+			err = resolved.Validate()
+		}
+		if err != nil {
+			fmt.Printf("Config %s is invalid and was not saved:\n%s\n", configName, err)
+			continue
+		}
+
 		config.Complete = true
 		configs[configName] = config
 	}
 
 	return internal.WriteInternalConfig(configs)
 }
+
+// ownOrInherited returns the value a config sets itself, or the one it inherits when it sets none.
+func ownOrInherited(own string, inherited string) string {
+	if own != "" {
+		return own
+	}
+
+	return inherited
+}
+
+// ownValue returns the value to store for an answer. An answer that repeats the inherited value of an unset field
+// leaves it unset.
+func ownValue(answer string, own string, inherited string) string {
+	if own == "" && answer == inherited {
+		return ""
+	}
+
+	return answer
+}
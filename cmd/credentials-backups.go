@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"time"
+)
+
+var credentialsBackupsCmd = &cobra.Command{
+	Use:               "backups",
+	Short:             "Lists backups of the AWS credentials file",
+	Long:              `Lists backups of the AWS credentials file, newest first`,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backups, err := internal.ListAwsCredentialsBackups()
+		if err != nil {
+			return err
+		}
+
+		if len(backups) == 0 {
+			fmt.Println("no backups found")
+			return nil
+		}
+
+		for _, backup := range backups {
+			fmt.Printf("%s\t%s\n", backup.Name, backup.CreatedAt.Local().Format(time.DateTime))
+		}
+		return nil
+	},
+}
+
+func init() {
+	credentialsCmd.AddCommand(credentialsBackupsCmd)
+}
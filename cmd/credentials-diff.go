@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var credentialsDiffShowSecrets bool
+
+var credentialsDiffCmd = &cobra.Command{
+	Use:               "diff [backup]",
+	Short:             "Shows the changes since a backup of the AWS credentials file",
+	Long:              `Shows the changes from a backup, the latest one by default, to the current AWS credentials file`,
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backup := ""
+		if len(args) == 1 {
+			backup = args[0]
+		}
+
+		diff, err := internal.DiffAwsCredentialsBackup(backup, credentialsDiffShowSecrets)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(diff)
+		return nil
+	},
+}
+
+func init() {
+	credentialsDiffCmd.Flags().BoolVar(&credentialsDiffShowSecrets, "show-secrets", false, "Shows secret keys and session tokens instead of masking them")
+	credentialsCmd.AddCommand(credentialsDiffCmd)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var credentialsRestoreCmd = &cobra.Command{
+	Use:               "restore [backup]",
+	Short:             "Restores a backup of the AWS credentials file",
+	Long:              `Restores a backup, the latest one by default, of the AWS credentials file. The current file is backed up first`,
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backup := ""
+		if len(args) == 1 {
+			backup = args[0]
+		}
+
+		restored, err := internal.RestoreAwsCredentialsBackup(backup)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Restored %s\n", restored)
+		return nil
+	},
+}
+
+func init() {
+	credentialsCmd.AddCommand(credentialsRestoreCmd)
+}
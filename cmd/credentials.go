@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var credentialsCmd = &cobra.Command{
+	Use:               "credentials",
+	Short:             "Inspects and restores backups of the AWS credentials file",
+	Long:              `awsx backs up the AWS credentials file every time it modifies it. These commands inspect and restore those backups`,
+	DisableAutoGenTag: true,
+}
+
+func init() {
+	rootCmd.AddCommand(credentialsCmd)
+}
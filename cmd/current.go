@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var currentCmd = &cobra.Command{
+	Use:               "current",
+	Short:             "Shows the config and profile used when none is given",
+	Long:              `Shows the current context set with "awsx use", and the .awsx.yaml that overrides it in the current directory`,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := internal.CurrentConfigContext()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(context)
+
+		projectConfig, err := internal.FindProjectConfig(".")
+		if err != nil {
+			return err
+		}
+
+		if projectConfig != nil {
+			projectContext := internal.ConfigContext{Config: projectConfig.Config, Profile: projectConfig.Profile}
+			if projectConfig.Allowed {
+				fmt.Printf("overridden in this directory by %s: %s\n", projectConfig.Path, projectContext)
+			} else {
+				fmt.Printf("%s would override it with %s, but it is not allowed\n", projectConfig.Path, projectContext)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(currentCmd)
+}
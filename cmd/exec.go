@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+var execBreakGlass string
+
+// static credentials in the environment take precedence over AWS_PROFILE and must not leak into the command
+var execClearedEnvironment = []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION"}
+
+var execCmd = &cobra.Command{
+	Use:   "exec [config] -- <command> [args...]",
+	Short: "Runs a command with the credentials of a profile",
+	Long: `Writes credentials for a profile like "awsx select" and runs the command with AWS_PROFILE and AWS_REGION set to it.
+Without a config name the nearest allowed .awsx.yaml picks the config, profile, account, role and region, and the current context is used when there is none. The command exits with the exit code of the command it runs`,
+	Example:           "awsx exec -- terraform plan\nawsx exec my-sso-config -- aws s3 ls",
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dash := cmd.ArgsLenAtDash()
+		if dash < 0 || dash == len(args) {
+			return errors.New("no command was specified. pass it after --, for example \"awsx exec -- aws s3 ls\"")
+		}
+
+		configName, config, profile, err := selectProfile(cmd, args[:dash])
+		if err != nil {
+			return err
+		}
+
+		oidcApi, ssoApi := internal.InitClients(config)
+		if err = start("exec", configName, profile, oidcApi, ssoApi, config, execBreakGlass); err != nil {
+			return err
+		}
+
+		var environment []string
+		for _, variable := range os.Environ() {
+			name, _, _ := strings.Cut(variable, "=")
+			if !slices.Contains(execClearedEnvironment, name) {
+				environment = append(environment, variable)
+			}
+		}
+		environment = append(environment, internal.PathEnvironment()...)
+		environment = append(environment, "AWS_PROFILE="+profile.Name, "AWS_REGION="+profile.Region, "AWS_DEFAULT_REGION="+profile.Region)
+
+		command := exec.Command(args[dash], args[dash+1:]...)
+		command.Env = environment
+		command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+		err = command.Run()
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			os.Exit(exitError.ExitCode())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to run \"%s\": %w", args[dash], err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	execCmd.Flags().StringVar(&execBreakGlass, "break-glass", "", "Justification for using a sensitive role, recorded in the audit log")
+	rootCmd.AddCommand(execCmd)
+}
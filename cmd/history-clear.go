@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var historyClearCmd = &cobra.Command{
+	Use:               "clear [configs...]",
+	Short:             "Clears the usage history",
+	Long:              `Clears the usage history of the named configs, or of all configs when none is given. The refresh command and the frecency order of the pickers start over`,
+	Example:           "awsx history clear work",
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := internal.ClearUsageInformation(args); err != nil {
+			return err
+		}
+
+		fmt.Println("History cleared")
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.AddCommand(historyClearCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"github.com/vahid-haghighat/awsx/utilities"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+var historyConfigName string
+var historyOutput string
+
+type historyEntry struct {
+	Config      string    `json:"config"`
+	AccountId   string    `json:"account_id"`
+	AccountName string    `json:"account_name"`
+	Role        string    `json:"role"`
+	Profile     string    `json:"profile"`
+	UseCount    int       `json:"use_count"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+}
+
+var historyCmd = &cobra.Command{
+	Use:               "history",
+	Short:             "Lists the accounts and roles you used",
+	Long:              `Lists the accounts and roles you used per config, most frecent first. Frecency combines how often and how recently a role was used, and orders the account and role pickers`,
+	Example:           "awsx history\nawsx history --config work --output json",
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		history, err := internal.ReadUsageHistory()
+		if err != nil {
+			return err
+		}
+
+		configNames := utilities.Keys(history)
+		if historyConfigName != "" {
+			configNames = []string{historyConfigName}
+		}
+		sort.Strings(configNames)
+
+		entries := make([]historyEntry, 0)
+		for _, configName := range configNames {
+			for _, entry := range internal.SortByFrecency(history[configName]) {
+				entries = append(entries, historyEntry{
+					Config:      configName,
+					AccountId:   entry.AccountId,
+					AccountName: entry.AccountName,
+					Role:        entry.Role,
+					Profile:     entry.Profile,
+					UseCount:    entry.UseCount,
+					LastUsedAt:  entry.LastUsedAt,
+				})
+			}
+		}
+
+		switch historyOutput {
+		case "json":
+			content, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(content))
+		case "table":
+			if len(entries) == 0 {
+				fmt.Println("no history found")
+				return nil
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintln(writer, "CONFIG\tACCOUNT\tACCOUNT ID\tROLE\tPROFILE\tUSES\tLAST USED")
+			for _, entry := range entries {
+				lastUsed := "unknown"
+				if !entry.LastUsedAt.IsZero() {
+					lastUsed = entry.LastUsedAt.Local().Format(time.DateTime)
+				}
+				_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", entry.Config, entry.AccountName, entry.AccountId, entry.Role, entry.Profile, entry.UseCount, lastUsed)
+			}
+			return writer.Flush()
+		default:
+			return fmt.Errorf("unknown output format \"%s\". valid values are table and json", historyOutput)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVarP(&historyConfigName, "config", "c", "", "Only lists the history of this config")
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", "table", "Output format: table or json")
+	rootCmd.AddCommand(historyCmd)
+}
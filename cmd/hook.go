@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var hookCmd = &cobra.Command{
+	Use:               "hook <bash|zsh|fish>",
+	Short:             "Prints a shell hook that sets AWS_PROFILE from .awsx.yaml",
+	Long:              `Prints a shell hook that sets AWS_PROFILE to the profile of the nearest .awsx.yaml whenever you change directories, and unsets it again when you leave the project`,
+	Example:           "eval \"$(awsx hook bash)\"   # in ~/.bashrc\neval \"$(awsx hook zsh)\"    # in ~/.zshrc\nawsx hook fish | source     # in ~/.config/fish/config.fish",
+	Args:              cobra.ExactArgs(1),
+	ValidArgs:         []string{"bash", "zsh", "fish"},
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hook, err := internal.ShellHook(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(hook)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var initBundleSource string
+var initBundlePublicKey string
+
+var initCmd = &cobra.Command{
+	Use:               "init",
+	Short:             "Sets up awsx",
+	Long:              `Sets up awsx. With --bundle, installs a team bundle after verifying its signature against the given public key, which is pinned for later updates. Without it, starts the config wizard`,
+	Example:           "awsx init --bundle https://example.com/platform.bundle --public-key <base64-key>\nawsx init",
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if initBundleSource == "" {
+			return configCmd.RunE(cmd, args)
+		}
+
+		result, err := internal.InstallBundle(initBundleSource, initBundlePublicKey)
+		if err != nil {
+			return err
+		}
+
+		printBundleResult(result)
+		return nil
+	},
+}
+
+func init() {
+	initCmd.Flags().StringVar(&initBundleSource, "bundle", "", "Path or http(s) URL of a bundle signed with \"awsx bundle sign\"")
+	initCmd.Flags().StringVar(&initBundlePublicKey, "public-key", "", "Base64 encoded ed25519 public key the bundle must be signed with")
+	initCmd.MarkFlagsRequiredTogether("bundle", "public-key")
+	rootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// the audit log is rotated once it grows beyond maxAuditLogSize, keeping auditLogBackups rotated files
+const maxAuditLogSize = 10 << 20
+const auditLogBackups = 5
+
+// AuditRecord is one line of the audit log, written every time awsx obtains role credentials.
+type AuditRecord struct {
+	Time          time.Time `json:"time"`
+	Command       string    `json:"command"`
+	Config        string    `json:"config"`
+	StartUrl      string    `json:"start_url"`
+	AccountId     string    `json:"account_id"`
+	AccountName   string    `json:"account_name,omitempty"`
+	Role          string    `json:"role"`
+	Profile       string    `json:"profile,omitempty"`
+	Expiration    time.Time `json:"expiration"`
+	Hostname      string    `json:"hostname"`
+	User          string    `json:"user"`
+	Justification string    `json:"justification,omitempty"`
+}
+
+// AuditCredentials appends a record of credentials obtained for configName to the audit log, with the justification
+// given for sensitive roles. Failing to write the record is logged but does not fail the command, the credentials have
+// been issued already.
+func AuditCredentials(command string, configName string, config *Config, profileName string, accountId string, accountName string, role string, justification string, credentials *ssoTypes.RoleCredentials) {
+	hostname, _ := os.Hostname()
+	userName := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		userName = current.Username
+	}
+
+	record := AuditRecord{
+		Time:          time.Now().UTC(),
+		Command:       command,
+		Config:        configName,
+		StartUrl:      config.GetStartUrl(),
+		AccountId:     accountId,
+		AccountName:   accountName,
+		Role:          role,
+		Profile:       profileName,
+		Expiration:    time.UnixMilli(credentials.Expiration).UTC(),
+		Hostname:      hostname,
+		User:          userName,
+		Justification: justification,
+	}
+
+	if err := appendAuditRecord(record); err != nil {
+		log.Printf("Failed to write the audit log %s: %s", auditLogFileName(), err)
+	}
+}
+
+func appendAuditRecord(record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	fileName := auditLogFileName()
+	return withFileLock(fileName, func() error {
+		if err := rotateAuditLog(fileName); err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(fileName), 0700); err != nil {
+			return err
+		}
+
+		file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+
+		_, err = file.Write(append(line, '\n'))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	})
+}
+
+// rotateAuditLog renames audit.jsonl to audit.jsonl.1, shifting older files up, once it is too large. The caller holds
+// the lock of the audit log.
+func rotateAuditLog(fileName string) error {
+	info, err := os.Stat(fileName)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && info.Size() < maxAuditLogSize) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for index := auditLogBackups - 1; index >= 1; index-- {
+		err = os.Rename(rotatedAuditLogFileName(fileName, index), rotatedAuditLogFileName(fileName, index+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return os.Rename(fileName, rotatedAuditLogFileName(fileName, 1))
+}
+
+func rotatedAuditLogFileName(fileName string, index int) string {
+	return fmt.Sprintf("%s.%d", fileName, index)
+}
+
+// ReadAuditLog returns every record of the audit log and its rotated files, oldest first. Lines that cannot be parsed
+// are skipped.
+func ReadAuditLog() ([]AuditRecord, error) {
+	fileName := auditLogFileName()
+	fileNames := make([]string, 0, auditLogBackups+1)
+	for index := auditLogBackups; index >= 1; index-- {
+		fileNames = append(fileNames, rotatedAuditLogFileName(fileName, index))
+	}
+	fileNames = append(fileNames, fileName)
+
+	var records []AuditRecord
+	for _, name := range fileNames {
+		file, err := os.Open(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			record := AuditRecord{}
+			if json.Unmarshal(scanner.Bytes(), &record) == nil {
+				records = append(records, record)
+			}
+		}
+		err = scanner.Err()
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
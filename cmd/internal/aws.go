@@ -13,9 +13,11 @@ import (
 
 	"log"
 	"os/exec"
+	"path"
 	"runtime"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -23,23 +25,59 @@ const grantType = "urn:ietf:params:oauth:grant-type:device_code"
 const clientType = "public"
 const clientName = "awsx"
 
+// browserNone disables opening the verification URL
+const browserNone = "none"
+
+// a device authorization expires after ten minutes, waiting longer for another process to log in is pointless
+const loginLockTimeout = 10 * time.Minute
+
 func (ati ClientInformation) IsExpired() (bool, bool) {
 	return ati.AccessTokenExpiresAt.Before(time.Now()), ati.ClientSecretExpiresAt.Before(time.Now())
 }
 
-func ProcessClientInformation(configName string, startUrl string, oidcClient *ssooidc.Client) (*ClientInformation, error) {
+// ProcessClientInformation returns a valid access token for the config, logging in when needed. Only one process logs
+// in per config at a time, the others wait for it and reuse the token it cached.
+func ProcessClientInformation(configName string, config *Config, oidcClient *ssooidc.Client) (*ClientInformation, error) {
+	clientInformation, err := GetClientInformationForConfig(configName)
+	if err != nil {
+		return nil, err
+	}
+	if accessTokenExpired, clientSecretExpired := clientInformation.IsExpired(); !accessTokenExpired && !clientSecretExpired {
+		return clientInformation, nil
+	}
+
+	lockName := path.Join(cachePath(), "login-"+unsafeFileNameCharacters.ReplaceAllString(configName, "_"))
+	waiting := func() {
+		log.Println("Waiting for another awsx process to finish logging in...")
+	}
+
+	err = withFileLockTimeout(lockName, loginLockTimeout, waiting, func() error {
+		clientInformation, err = processClientInformation(configName, config.GetStartUrl(), config.Browser, oidcClient)
+		return err
+	})
+	if errors.Is(err, errLockTimeout) {
+		return nil, fmt.Errorf("timed out waiting for another awsx process to log in to config \"%s\"", configName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return clientInformation, nil
+}
+
+func processClientInformation(configName string, startUrl string, browser string, oidcClient *ssooidc.Client) (*ClientInformation, error) {
 	clientInformation, err := GetClientInformationForConfig(configName)
 	if err != nil {
-		return Register(configName, startUrl, oidcClient)
+		return nil, err
 	}
 
 	accessTokenExpired, clientSecretExpired := clientInformation.IsExpired()
 	if clientSecretExpired {
-		return Register(configName, startUrl, oidcClient)
+		return Register(configName, startUrl, browser, oidcClient)
 	}
 	if accessTokenExpired {
 		log.Println("AccessToken expired. Start retrieving a new AccessToken.")
-		clientInformation, err = HandleOutdatedAccessToken(configName, startUrl, clientInformation, oidcClient)
+		clientInformation, err = HandleOutdatedAccessToken(configName, startUrl, browser, clientInformation, oidcClient)
 		if err != nil {
 			return nil, err
 		}
@@ -47,8 +85,8 @@ func ProcessClientInformation(configName string, startUrl string, oidcClient *ss
 	return clientInformation, nil
 }
 
-func Register(configName string, startUrl string, oidcClient *ssooidc.Client) (*ClientInformation, error) {
-	clientInformation, err := registerClient(oidcClient, startUrl)
+func Register(configName string, startUrl string, browser string, oidcClient *ssooidc.Client) (*ClientInformation, error) {
+	clientInformation, err := registerClient(oidcClient, startUrl, browser)
 	if err != nil {
 		return nil, err
 	}
@@ -62,9 +100,9 @@ func Register(configName string, startUrl string, oidcClient *ssooidc.Client) (*
 	return clientInformation, nil
 }
 
-func HandleOutdatedAccessToken(configName string, startUrl string, clientInformation *ClientInformation, oidcClient *ssooidc.Client) (*ClientInformation, error) {
+func HandleOutdatedAccessToken(configName string, startUrl string, browser string, clientInformation *ClientInformation, oidcClient *ssooidc.Client) (*ClientInformation, error) {
 	registerClientOutput := ssooidc.RegisterClientOutput{ClientId: &clientInformation.ClientId, ClientSecret: &clientInformation.ClientSecret}
-	sda, err := startDeviceAuthorization(oidcClient, &registerClientOutput, startUrl)
+	sda, err := startDeviceAuthorization(oidcClient, &registerClientOutput, startUrl, browser)
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +129,7 @@ func generateCreateTokenInput(clientInformation *ClientInformation) ssooidc.Crea
 	}
 }
 
-func registerClient(oidc *ssooidc.Client, startUrl string) (*ClientInformation, error) {
+func registerClient(oidc *ssooidc.Client, startUrl string, browser string) (*ClientInformation, error) {
 	cn := clientName
 	ct := clientType
 
@@ -101,7 +139,7 @@ func registerClient(oidc *ssooidc.Client, startUrl string) (*ClientInformation,
 		return nil, err
 	}
 
-	sdao, err := startDeviceAuthorization(oidc, rco, startUrl)
+	sdao, err := startDeviceAuthorization(oidc, rco, startUrl, browser)
 	if err != nil {
 		return nil, err
 	}
@@ -116,20 +154,34 @@ func registerClient(oidc *ssooidc.Client, startUrl string) (*ClientInformation,
 	}, nil
 }
 
-func startDeviceAuthorization(ssoClient *ssooidc.Client, rco *ssooidc.RegisterClientOutput, startUrl string) (*ssooidc.StartDeviceAuthorizationOutput, error) {
+func startDeviceAuthorization(ssoClient *ssooidc.Client, rco *ssooidc.RegisterClientOutput, startUrl string, browser string) (*ssooidc.StartDeviceAuthorizationOutput, error) {
 	sdao, err := ssoClient.StartDeviceAuthorization(context.Background(), &ssooidc.StartDeviceAuthorizationInput{ClientId: rco.ClientId, ClientSecret: rco.ClientSecret, StartUrl: &startUrl})
 	if err != nil {
 		return nil, err
 	}
 
 	log.Println("Please verify your client request: " + *sdao.VerificationUriComplete)
-	openUrlInBrowser(*sdao.VerificationUriComplete)
+	openUrlInBrowser(*sdao.VerificationUriComplete, browser)
 	return sdao, nil
 }
 
-func openUrlInBrowser(url string) {
+// openUrlInBrowser opens url with the browser command of the config, or the default browser when it is empty. The
+// browser "none" only prints the url, which suits machines without a desktop.
+func openUrlInBrowser(url string, browser string) {
 	var err error
 
+	if browser == browserNone {
+		return
+	}
+
+	if browser != "" {
+		fields := strings.Fields(browser)
+		if err = exec.Command(fields[0], append(fields[1:], url)...).Start(); err != nil {
+			log.Printf("Failed to start browser \"%s\": %s. Please open the URL manually", browser, err)
+		}
+		return
+	}
+
 	switch runtime.GOOS {
 	case "linux":
 		err = exec.Command("xdg-open", url).Start()
@@ -177,16 +229,25 @@ func InitClients(config *Config) (*ssooidc.Client, *sso.Client) {
 	return oidcClient, ssoClient
 }
 
-func RetrieveRoleInfo(accountInfo ssoTypes.AccountInfo, clientInformation *ClientInformation, ssoClient *sso.Client, selector Prompt) ssoTypes.RoleInfo {
+// RetrieveRoleInfo lets the user pick a role of the account, the roles used most frecently in configName first.
+func RetrieveRoleInfo(configName string, accountInfo ssoTypes.AccountInfo, clientInformation *ClientInformation, ssoClient *sso.Client, selector Prompt) (ssoTypes.RoleInfo, error) {
 	lari := &sso.ListAccountRolesInput{AccountId: accountInfo.AccountId, AccessToken: &clientInformation.AccessToken}
-	roles, _ := ssoClient.ListAccountRoles(context.Background(), lari)
+	roles, err := ssoClient.ListAccountRoles(context.Background(), lari)
+	if err != nil {
+		return ssoTypes.RoleInfo{}, err
+	}
+
+	if len(roles.RoleList) == 0 {
+		return ssoTypes.RoleInfo{}, fmt.Errorf("no roles available in account %s", *accountInfo.AccountId)
+	}
 
 	if len(roles.RoleList) == 1 {
 		log.Printf("Only one role available. Selected role: %s\n", *roles.RoleList[0].RoleName)
-		return roles.RoleList[0]
+		return roles.RoleList[0], nil
 	}
 
-	sortedRoles := sortRoles(roles.RoleList)
+	history, _ := GetUsageInformationForConfig(configName)
+	sortedRoles := sortRoles(roles.RoleList, roleFrecencies(history, *accountInfo.AccountId))
 	var rolesToSelect []string
 	linePrefix := "#"
 
@@ -195,51 +256,78 @@ func RetrieveRoleInfo(accountInfo ssoTypes.AccountInfo, clientInformation *Clien
 	}
 
 	label := "Select your role - Hint: fuzzy search supported. To choose one role directly just enter #{Int}"
-	indexChoice, _, _ := selector.Select(label, rolesToSelect, fuzzySearchWithPrefixAnchor(rolesToSelect, linePrefix))
+	indexChoice, _, err := selector.Select(label, rolesToSelect, fuzzySearchWithPrefixAnchor(rolesToSelect, linePrefix))
+	if err != nil {
+		return ssoTypes.RoleInfo{}, err
+	}
+
 	roleInfo := sortedRoles[indexChoice]
-	return roleInfo
+	return roleInfo, nil
 }
 
-func RetrieveAccountInfo(clientInformation *ClientInformation, ssoClient *sso.Client, selector Prompt) ssoTypes.AccountInfo {
+// RetrieveAccountInfo lets the user pick an account, the accounts used most frecently in configName first.
+func RetrieveAccountInfo(configName string, clientInformation *ClientInformation, ssoClient *sso.Client, selector Prompt) (ssoTypes.AccountInfo, error) {
 	var maxSize int32 = 1000 // default is 20
 	lai := sso.ListAccountsInput{AccessToken: &clientInformation.AccessToken, MaxResults: &maxSize}
-	accounts, _ := ssoClient.ListAccounts(context.Background(), &lai)
+	accounts, err := ssoClient.ListAccounts(context.Background(), &lai)
+	if err != nil {
+		return ssoTypes.AccountInfo{}, err
+	}
 
-	sortedAccounts := sortAccounts(accounts.AccountList)
+	history, _ := GetUsageInformationForConfig(configName)
+	sortedAccounts := sortAccounts(accounts.AccountList, accountFrecencies(history))
 
 	var accountsToSelect []string
 	linePrefix := "#"
 
+	annotations := AccountAnnotations()
 	for i, info := range sortedAccounts {
-		accountsToSelect = append(accountsToSelect, linePrefix+strconv.Itoa(i)+" "+*info.AccountName+" "+*info.AccountId)
+		line := linePrefix + strconv.Itoa(i) + " " + *info.AccountName + " " + *info.AccountId
+		if annotation, ok := annotations[*info.AccountId]; ok && annotation != nil {
+			line += " - " + annotation.String()
+		}
+		accountsToSelect = append(accountsToSelect, line)
 	}
 
 	label := "Select your account - Hint: fuzzy search supported. To choose one account directly just enter #{Int}"
-	indexChoice, _, _ := selector.Select(label, accountsToSelect, fuzzySearchWithPrefixAnchor(accountsToSelect, linePrefix))
+	indexChoice, _, err := selector.Select(label, accountsToSelect, fuzzySearchWithPrefixAnchor(accountsToSelect, linePrefix))
+	if err != nil {
+		return ssoTypes.AccountInfo{}, err
+	}
 
 	accountInfo := sortedAccounts[indexChoice]
 
 	log.Printf("Selected account: %s - %s", *accountInfo.AccountName, *accountInfo.AccountId)
-	return accountInfo
+	return accountInfo, nil
 }
 
-func sortAccounts(accountList []ssoTypes.AccountInfo) []ssoTypes.AccountInfo {
+// sortAccounts orders accounts by frecency, and alphabetically where there is no history
+func sortAccounts(accountList []ssoTypes.AccountInfo, frecencies map[string]float64) []ssoTypes.AccountInfo {
 	var sortedAccounts []ssoTypes.AccountInfo
 	for _, info := range accountList {
 		sortedAccounts = append(sortedAccounts, info)
 	}
 	sort.Slice(sortedAccounts, func(i, j int) bool {
+		left, right := frecencies[*sortedAccounts[i].AccountId], frecencies[*sortedAccounts[j].AccountId]
+		if left != right {
+			return left > right
+		}
 		return *sortedAccounts[i].AccountName < *sortedAccounts[j].AccountName
 	})
 	return sortedAccounts
 }
 
-func sortRoles(rolesList []ssoTypes.RoleInfo) []ssoTypes.RoleInfo {
+// sortRoles orders roles by frecency, and alphabetically where there is no history
+func sortRoles(rolesList []ssoTypes.RoleInfo, frecencies map[string]float64) []ssoTypes.RoleInfo {
 	var sortedRoles []ssoTypes.RoleInfo
 	for _, role := range rolesList {
 		sortedRoles = append(sortedRoles, role)
 	}
 	sort.Slice(sortedRoles, func(i, j int) bool {
+		left, right := frecencies[*sortedRoles[i].RoleName], frecencies[*sortedRoles[j].RoleName]
+		if left != right {
+			return left > right
+		}
 		return *sortedRoles[i].RoleName < *sortedRoles[j].RoleName
 	})
 	return sortedRoles
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/manifoldco/promptui"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SensitiveRule marks accounts and roles that need a confirmation and a justification before awsx issues credentials
+// for them. AccountName and Role are glob patterns such as "*prod*". A rule matches when all of its fields match.
+type SensitiveRule struct {
+	AccountId   string `yaml:"account_id,omitempty"`
+	AccountName string `yaml:"account_name,omitempty"`
+	Role        string `yaml:"role,omitempty"`
+}
+
+func (r SensitiveRule) String() string {
+	var fields []string
+	if r.AccountId != "" {
+		fields = append(fields, "account id "+r.AccountId)
+	}
+	if r.AccountName != "" {
+		fields = append(fields, "account name "+r.AccountName)
+	}
+	if r.Role != "" {
+		fields = append(fields, "role "+r.Role)
+	}
+
+	return strings.Join(fields, ", ")
+}
+
+// Validate fails for rules without any field, and for malformed account ids or patterns.
+func (r SensitiveRule) Validate() error {
+	if r.AccountId == "" && r.AccountName == "" && r.Role == "" {
+		return errors.New("sensitive rule must set an account id, an account name or a role")
+	}
+
+	if r.AccountId != "" && !accountIdPattern.MatchString(r.AccountId) {
+		return fmt.Errorf("sensitive rule: account id \"%s\" must be 12 digits", r.AccountId)
+	}
+
+	for _, pattern := range []string{r.AccountName, r.Role} {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("sensitive rule: invalid pattern \"%s\"", pattern)
+		}
+	}
+
+	return nil
+}
+
+// Matches tells whether the role of the account falls under the rule. Name patterns ignore case. An empty accountName
+// means the name could not be resolved, so an account name pattern matches it rather than letting the role through.
+func (r SensitiveRule) Matches(accountId string, accountName string, role string) bool {
+	return (r.AccountId == "" || r.AccountId == accountId) &&
+		(r.AccountName == "" || accountName == "" || matchesPattern(r.AccountName, accountName)) &&
+		(r.Role == "" || matchesPattern(r.Role, role))
+}
+
+func matchesPattern(pattern string, value string) bool {
+	matched, err := filepath.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && matched
+}
+
+// MatchingSensitiveRule returns the first rule of the config the role of the account falls under, or nil.
+func (c *Config) MatchingSensitiveRule(accountId string, accountName string, role string) *SensitiveRule {
+	for _, rule := range c.Sensitive {
+		if rule.Matches(accountId, accountName, role) {
+			return &rule
+		}
+	}
+
+	return nil
+}
+
+// LookupAccountName returns the name of the account for profiles that only know its id, so account name patterns can
+// match them. The usage history is consulted first, the access portal only when the config has such patterns. An empty
+// name is returned when neither knows the account, which account name patterns treat as a match.
+func LookupAccountName(configName string, config *Config, accountId string, clientInformation *ClientInformation, ssoClient *sso.Client) string {
+	history, _ := GetUsageInformationForConfig(configName)
+	for _, entry := range history {
+		if entry.AccountId == accountId && entry.AccountName != "" {
+			return entry.AccountName
+		}
+	}
+
+	namePatterns := false
+	for _, rule := range config.Sensitive {
+		namePatterns = namePatterns || rule.AccountName != ""
+	}
+	if !namePatterns {
+		return ""
+	}
+
+	var maxSize int32 = 1000
+	accounts, err := ssoClient.ListAccounts(context.Background(), &sso.ListAccountsInput{AccessToken: &clientInformation.AccessToken, MaxResults: &maxSize})
+	if err != nil {
+		return ""
+	}
+
+	for _, account := range accounts.AccountList {
+		if *account.AccountId == accountId && account.AccountName != nil {
+			return *account.AccountName
+		}
+	}
+
+	return ""
+}
+
+// BreakGlass guards the sensitive accounts and roles of a config. Reason is the justification given up front with
+// --break-glass. Without it, non-interactive commands refuse sensitive roles.
+type BreakGlass struct {
+	Reason         string
+	NonInteractive bool
+	Selector       Prompt
+}
+
+// Confirm shows a banner for a sensitive role of the account and asks the user to type the account name and a
+// justification. It returns the justification to record in the audit log, or an empty one when the role is not
+// sensitive.
+func (b BreakGlass) Confirm(config *Config, accountId string, accountName string, role string) (string, error) {
+	rule := config.MatchingSensitiveRule(accountId, accountName, role)
+	if rule == nil {
+		return "", nil
+	}
+
+	account := accountId
+	if accountName != "" {
+		account = fmt.Sprintf("%s (%s)", accountName, accountId)
+	}
+
+	unresolved := ""
+	if accountName == "" && rule.AccountName != "" {
+		unresolved = "; the account name could not be resolved"
+	}
+
+	reason := strings.TrimSpace(b.Reason)
+	if b.NonInteractive && reason == "" {
+		return "", fmt.Errorf("role \"%s\" of account %s is sensitive (%s%s). pass --break-glass \"reason\" to use it non-interactively", role, account, rule, unresolved)
+	}
+
+	banner := promptui.Styler(promptui.BGRed, promptui.FGWhite, promptui.FGBold)
+	_, _ = fmt.Fprintln(os.Stderr, banner(fmt.Sprintf(" BREAK GLASS: role %s of account %s is sensitive (%s%s) ", role, account, rule, unresolved)))
+
+	if b.NonInteractive {
+		return reason, nil
+	}
+
+	selector := b.Selector
+	if selector == nil {
+		var err error
+		if selector, err = DefaultSelector(); err != nil {
+			return "", err
+		}
+	}
+
+	confirmation := accountName
+	if confirmation == "" {
+		confirmation = accountId
+	}
+
+	typed, err := selector.Prompt(fmt.Sprintf("Type %s to confirm", confirmation), "")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(typed) != confirmation {
+		return "", fmt.Errorf("\"%s\" does not match \"%s\". no credentials were issued", typed, confirmation)
+	}
+
+	justification := reason
+	if justification == "" {
+		if justification, err = selector.Prompt("Justification", ""); err != nil {
+			return "", err
+		}
+	}
+
+	justification = strings.TrimSpace(justification)
+	if justification == "" {
+		return "", errors.New("a justification is required for sensitive roles. no credentials were issued")
+	}
+
+	return justification, nil
+}
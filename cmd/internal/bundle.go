@@ -0,0 +1,475 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const bundleFetchTimeout = 30 * time.Second
+
+// bundles are small, anything larger is most likely not a bundle at all
+const maxBundleSize = 10 << 20
+
+// Bundle is what a team publishes: configs, shared defaults and notes shown next to accounts in the account picker.
+// Serial grows with every release, so older releases cannot replace newer ones.
+type Bundle struct {
+	Name     string                        `yaml:"name"`
+	Serial   int                           `yaml:"serial"`
+	Defaults *Config                       `yaml:"defaults,omitempty"`
+	Configs  map[string]*Config            `yaml:"configs"`
+	Accounts map[string]*AccountAnnotation `yaml:"accounts,omitempty"`
+}
+
+type AccountAnnotation struct {
+	Description string   `yaml:"description,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+}
+
+func (a *AccountAnnotation) String() string {
+	parts := make([]string, 0, 2)
+	if a.Description != "" {
+		parts = append(parts, a.Description)
+	}
+	if len(a.Tags) > 0 {
+		parts = append(parts, "["+strings.Join(a.Tags, ", ")+"]")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// signedBundle is the file that is distributed. The signature covers the exact bytes of the payload.
+type signedBundle struct {
+	Payload   string `yaml:"payload"`
+	Signature string `yaml:"signature"`
+}
+
+// InstalledBundle records the bundle the config file was set up from, so it can be updated without touching the
+// configs and profiles added locally.
+type InstalledBundle struct {
+	Name      string                        `yaml:"name"`
+	Source    string                        `yaml:"source"`
+	PublicKey string                        `yaml:"public_key"`
+	Serial    int                           `yaml:"serial"`
+	UpdatedAt time.Time                     `yaml:"updated_at"`
+	Configs   map[string][]string           `yaml:"configs,omitempty"`
+	Defaults  bool                          `yaml:"defaults,omitempty"`
+	Accounts  map[string]*AccountAnnotation `yaml:"accounts,omitempty"`
+}
+
+// BundleResult describes what installing or updating a bundle changed.
+type BundleResult struct {
+	Name     string
+	Serial   int
+	Added    []string
+	Updated  []string
+	Removed  []string
+	Warnings []string
+	UpToDate bool
+}
+
+// GenerateBundleKey returns a new ed25519 key pair, both base64 encoded.
+func GenerateBundleKey() (publicKey string, privateKey string, err error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(public), base64.StdEncoding.EncodeToString(private), nil
+}
+
+// SignBundle checks payload and wraps it together with its signature into a distributable bundle.
+func SignBundle(payload []byte, privateKey string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(privateKey))
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid private key. expected a base64 encoded ed25519 key as written by \"awsx bundle keygen\"")
+	}
+
+	if _, err = parseBundle(payload); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(&signedBundle{
+		Payload:   string(payload),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+	})
+}
+
+// VerifyBundle checks the signature of content against publicKey and returns the bundle it contains.
+func VerifyBundle(content []byte, publicKey string) (*Bundle, error) {
+	key, err := decodeBundlePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := signedBundle{}
+	if err = yaml.Unmarshal(content, &signed); err != nil || signed.Payload == "" {
+		return nil, errors.New("invalid bundle. expected a file written by \"awsx bundle sign\"")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil || !ed25519.Verify(key, []byte(signed.Payload), signature) {
+		return nil, errors.New("the signature of the bundle does not match the pinned public key")
+	}
+
+	return parseBundle([]byte(signed.Payload))
+}
+
+func decodeBundlePublicKey(publicKey string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key. expected a base64 encoded ed25519 public key")
+	}
+
+	return key, nil
+}
+
+func parseBundle(payload []byte) (*Bundle, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(payload))
+	decoder.KnownFields(true)
+
+	bundle := &Bundle{}
+	if err := decoder.Decode(bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	var problems []error
+	if bundle.Name == "" {
+		problems = append(problems, errors.New("name cannot be empty"))
+	}
+
+	if bundle.Serial < 1 {
+		problems = append(problems, errors.New("serial must be at least 1"))
+	}
+
+	if len(bundle.Configs) == 0 {
+		problems = append(problems, errors.New("the bundle has no configs"))
+	}
+
+	for accountId := range bundle.Accounts {
+		if !accountIdPattern.MatchString(accountId) {
+			problems = append(problems, fmt.Errorf("account id \"%s\" must be 12 digits", accountId))
+		}
+	}
+
+	if bundle.Defaults != nil {
+		problems = append(problems, bundleCommandProblems("defaults", bundle.Defaults)...)
+	}
+
+	for configName, config := range bundle.Configs {
+		if config == nil {
+			problems = append(problems, fmt.Errorf("config \"%s\" is empty", configName))
+			continue
+		}
+
+		problems = append(problems, bundleCommandProblems(fmt.Sprintf("config \"%s\"", configName), config)...)
+
+		config.Complete = true
+		for profileName, profile := range config.Profiles {
+			if profile != nil {
+				profile.Name = profileName
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		// the configs of a bundle may only inherit from each other and from its defaults
+		configs, err := (&ConfigFile{Defaults: bundle.Defaults, Configs: bundle.Configs}).ResolveConfigs()
+		if err != nil {
+			problems = append(problems, err)
+		} else if err = ValidateConfigs(configs); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid bundle:\n%w", errors.Join(problems...))
+	}
+
+	return bundle, nil
+}
+
+// bundleCommandProblems rejects the settings that make awsx run a program. A bundle is updated without a review of
+// its content, so it must not be able to choose the commands that run on the machines it is installed on.
+func bundleCommandProblems(owner string, config *Config) []error {
+	var problems []error
+	if config.Browser != "" {
+		problems = append(problems, fmt.Errorf("%s: browser cannot be set by a bundle", owner))
+	}
+
+	if strings.HasPrefix(config.TokenStorage, TokenStorageCommandPrefix) {
+		problems = append(problems, fmt.Errorf("%s: token_storage \"%s\" runs a command and cannot be set by a bundle", owner, config.TokenStorage))
+	}
+
+	return problems
+}
+
+// FetchBundle reads a bundle from a file or from an http(s) URL.
+func FetchBundle(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "https://") && !strings.HasPrefix(source, "http://") {
+		return os.ReadFile(source)
+	}
+
+	client := http.Client{Timeout: bundleFetchTimeout}
+	response, err := client.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the bundle: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download the bundle from %s: %s", source, response.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxBundleSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the bundle: %w", err)
+	}
+	if len(content) > maxBundleSize {
+		return nil, fmt.Errorf("the bundle at %s is larger than %d bytes", source, maxBundleSize)
+	}
+
+	return content, nil
+}
+
+// InstallBundle verifies the bundle at source against publicKey, pins both and merges the bundle into the config file.
+func InstallBundle(source string, publicKey string) (*BundleResult, error) {
+	// updates run from other directories
+	if !strings.HasPrefix(source, "https://") && !strings.HasPrefix(source, "http://") {
+		absolute, err := filepath.Abs(source)
+		if err != nil {
+			return nil, err
+		}
+		source = absolute
+	}
+
+	bundle, err := fetchVerifiedBundle(source, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BundleResult{}
+	err = updateInternalConfigFile(func(configFile *ConfigFile) error {
+		if configFile.Bundle != nil && configFile.Bundle.Name != bundle.Name {
+			return fmt.Errorf("bundle \"%s\" is already installed and cannot be replaced by bundle \"%s\"", configFile.Bundle.Name, bundle.Name)
+		}
+
+		if configFile.Bundle != nil && bundle.Serial < configFile.Bundle.Serial {
+			return fmt.Errorf("serial %d of the bundle is older than the installed serial %d", bundle.Serial, configFile.Bundle.Serial)
+		}
+
+		result, err = applyBundle(configFile, bundle, source, publicKey)
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateBundle downloads the installed bundle again from where it was installed and merges it when it is newer.
+func UpdateBundle() (*BundleResult, error) {
+	configFile, err := ReadInternalConfigFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if configFile.Bundle == nil {
+		return nil, errors.New("no bundle is installed. run \"awsx init --bundle <path-or-url> --public-key <key>\" first")
+	}
+
+	source, publicKey := configFile.Bundle.Source, configFile.Bundle.PublicKey
+	bundle, err := fetchVerifiedBundle(source, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BundleResult{Name: bundle.Name, Serial: bundle.Serial}
+	err = updateInternalConfigFile(func(configFile *ConfigFile) error {
+		if configFile.Bundle == nil || configFile.Bundle.PublicKey != publicKey || configFile.Bundle.Source != source {
+			return errors.New("the installed bundle changed while it was being updated. please try again")
+		}
+
+		if bundle.Name != configFile.Bundle.Name {
+			return fmt.Errorf("%s now serves bundle \"%s\" instead of \"%s\"", source, bundle.Name, configFile.Bundle.Name)
+		}
+
+		if bundle.Serial < configFile.Bundle.Serial {
+			return fmt.Errorf("serial %d of the bundle is older than the installed serial %d", bundle.Serial, configFile.Bundle.Serial)
+		}
+
+		if bundle.Serial == configFile.Bundle.Serial {
+			result.UpToDate = true
+			return nil
+		}
+
+		result, err = applyBundle(configFile, bundle, source, publicKey)
+		return err
+	})
+
+	return result, err
+}
+
+func fetchVerifiedBundle(source string, publicKey string) (*Bundle, error) {
+	if _, err := decodeBundlePublicKey(publicKey); err != nil {
+		return nil, err
+	}
+
+	content, err := FetchBundle(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return VerifyBundle(content, publicKey)
+}
+
+// applyBundle replaces the configs of the previous release of the bundle with the ones of bundle. Configs that exist
+// locally are kept, and so are profiles, sensitive rules, the browser and the token storage set locally in the configs
+// of the bundle.
+func applyBundle(configFile *ConfigFile, bundle *Bundle, source string, publicKey string) (*BundleResult, error) {
+	result := &BundleResult{Name: bundle.Name, Serial: bundle.Serial}
+	previous := configFile.Bundle
+	if previous == nil {
+		previous = &InstalledBundle{}
+	}
+
+	installed := &InstalledBundle{
+		Name:      bundle.Name,
+		Source:    source,
+		PublicKey: publicKey,
+		Serial:    bundle.Serial,
+		UpdatedAt: time.Now().UTC(),
+		Configs:   make(map[string][]string),
+		Accounts:  bundle.Accounts,
+	}
+
+	input := make(map[string]*Config)
+	for configName, config := range configFile.Configs {
+		input[configName] = config
+	}
+
+	for configName := range previous.Configs {
+		if _, ok := bundle.Configs[configName]; !ok {
+			delete(input, configName)
+			result.Removed = append(result.Removed, configName)
+		}
+	}
+
+	for configName, config := range bundle.Configs {
+		local, exists := input[configName]
+		previousProfiles, managed := previous.Configs[configName]
+		if exists && !managed {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("config \"%s\" already exists locally and was kept", configName))
+			continue
+		}
+
+		installed.Configs[configName] = profileNames(config)
+		if !exists {
+			result.Added = append(result.Added, configName)
+		} else {
+			result.Updated = append(result.Updated, configName)
+			result.Warnings = append(result.Warnings, keepLocalSettings(fmt.Sprintf("config \"%s\"", configName), fmt.Sprintf(" --config %s", configName), local, config)...)
+			for profileName, profile := range local.Profiles {
+				if slices.Contains(previousProfiles, profileName) {
+					continue
+				}
+
+				if _, ok := config.Profiles[profileName]; ok {
+					result.Warnings = append(result.Warnings, fmt.Sprintf("profile \"%s\" of config \"%s\" was added locally and is now part of the bundle. the local one was replaced", profileName, configName))
+					continue
+				}
+
+				if config.Profiles == nil {
+					config.Profiles = make(map[string]*Profile)
+				}
+				config.Profiles[profileName] = profile
+			}
+		}
+
+		input[configName] = config
+	}
+
+	if bundle.Defaults != nil && configFile.Defaults != nil && !previous.Defaults {
+		result.Warnings = append(result.Warnings, "the local defaults were kept instead of the defaults of the bundle")
+	} else if bundle.Defaults != nil {
+		if configFile.Defaults != nil {
+			result.Warnings = append(result.Warnings, keepLocalSettings("defaults", "", configFile.Defaults, bundle.Defaults)...)
+		}
+		configFile.Defaults = bundle.Defaults
+		installed.Defaults = true
+	} else if previous.Defaults {
+		configFile.Defaults = nil
+	}
+
+	configFile.Configs = mergeCompleteConfigs(configFile.Configs, input)
+	configFile.Bundle = installed
+
+	configs, err := configFile.ResolveConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("the bundle cannot be merged: %w", err)
+	}
+	if err = ValidateConfigs(configs); err != nil {
+		return nil, fmt.Errorf("the bundle cannot be merged:\n%w", err)
+	}
+
+	if context, err := ParseConfigContext(configFile.CurrentContext); err == nil {
+		if _, ok := configFile.Configs[context.Config]; !ok {
+			configFile.CurrentContext = ""
+		}
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Warnings)
+	return result, nil
+}
+
+// keepLocalSettings carries the settings made on this machine over to the new release of a config of the bundle.
+// Sensitive rules added locally are kept next to the ones of the bundle. The browser and the token storage stay the
+// local ones, switching the storage needs a migration of the cached tokens that only "awsx config storage" does.
+func keepLocalSettings(owner string, storageFlag string, local *Config, config *Config) []string {
+	var warnings []string
+	for _, rule := range local.Sensitive {
+		if !slices.Contains(config.Sensitive, rule) {
+			config.Sensitive = append(config.Sensitive, rule)
+		}
+	}
+
+	if config.TokenStorage != "" && config.TokenStorage != local.TokenStorage {
+		warnings = append(warnings, fmt.Sprintf("%s: the bundle sets token_storage \"%s\", the local storage was kept. run \"awsx config storage %s%s\" to switch and migrate the cached tokens", owner, config.TokenStorage, config.TokenStorage, storageFlag))
+	}
+	config.TokenStorage = local.TokenStorage
+	config.Browser = local.Browser
+
+	return warnings
+}
+
+func profileNames(config *Config) []string {
+	names := make([]string, 0, len(config.Profiles))
+	for profileName := range config.Profiles {
+		names = append(names, profileName)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// AccountAnnotations returns the notes of the installed bundle keyed by account id.
+func AccountAnnotations() map[string]*AccountAnnotation {
+	configFile, err := ReadInternalConfigFile()
+	if err != nil || configFile.Bundle == nil {
+		return nil
+	}
+
+	return configFile.Bundle.Accounts
+}
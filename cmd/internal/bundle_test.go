@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testBundlePayload = `name: platform
+serial: %d
+configs:
+  work:
+    Id: d-1234567890
+    sso_region: eu-west-1
+    last_used_accounts_count: 3
+    %s
+    profiles:
+      default:
+        region: eu-west-1
+`
+
+func testBundleKeys(t *testing.T) (string, string) {
+	t.Helper()
+
+	publicKey, privateKey, err := GenerateBundleKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return publicKey, privateKey
+}
+
+func signTestBundle(t *testing.T, privateKey string, serial int, extra string) []byte {
+	t.Helper()
+
+	signed, err := SignBundle([]byte(fmt.Sprintf(testBundlePayload, serial, extra)), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func TestVerifyBundle(t *testing.T) {
+	publicKey, privateKey := testBundleKeys(t)
+	otherPublicKey, _ := testBundleKeys(t)
+	signed := signTestBundle(t, privateKey, 1, "")
+
+	tests := []struct {
+		name      string
+		content   []byte
+		publicKey string
+		wantErr   string
+	}{
+		{name: "valid", content: signed, publicKey: publicKey},
+		{name: "other key", content: signed, publicKey: otherPublicKey, wantErr: "does not match the pinned public key"},
+		{name: "tampered payload", content: []byte(strings.Replace(string(signed), "eu-west-1", "us-east-1", 1)), publicKey: publicKey, wantErr: "does not match the pinned public key"},
+		{name: "not a bundle", content: []byte("name: platform\n"), publicKey: publicKey, wantErr: "expected a file written by"},
+		{name: "invalid key", content: signed, publicKey: "not-a-key", wantErr: "invalid public key"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bundle, err := VerifyBundle(test.content, test.publicKey)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("VerifyBundle() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if bundle.Name != "platform" || bundle.Serial != 1 || bundle.Configs["work"].Profiles["default"].Name != "default" {
+				t.Errorf("VerifyBundle() = %+v", bundle)
+			}
+		})
+	}
+}
+
+func TestParseBundleRejectsCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "browser", extra: "browser: firefox", wantErr: "browser cannot be set by a bundle"},
+		{name: "command token storage", extra: "token_storage: \"command:pass show awsx\"", wantErr: "runs a command and cannot be set by a bundle"},
+		{name: "other token storage", extra: "token_storage: encrypted"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseBundle([]byte(fmt.Sprintf(testBundlePayload, 1, test.extra)))
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("parseBundle() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestBundleSerials(t *testing.T) {
+	directory := isolatePaths(t)
+	publicKey, privateKey := testBundleKeys(t)
+	source := filepath.Join(directory, "platform.bundle")
+
+	writeTestFile(t, source, string(signTestBundle(t, privateKey, 2, "")))
+	if _, err := InstallBundle(source, publicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		serial       int
+		wantErr      string
+		wantUpToDate bool
+	}{
+		{name: "same serial", serial: 2, wantUpToDate: true},
+		{name: "rollback", serial: 1, wantErr: "older than the installed serial 2"},
+		{name: "newer serial", serial: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			writeTestFile(t, source, string(signTestBundle(t, privateKey, test.serial, "")))
+
+			result, err := UpdateBundle()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("UpdateBundle() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.UpToDate != test.wantUpToDate {
+				t.Errorf("UpdateBundle() up to date = %v, want %v", result.UpToDate, test.wantUpToDate)
+			}
+		})
+	}
+
+	if _, err := InstallBundle(source, publicKey); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, source, string(signTestBundle(t, privateKey, 1, "")))
+	if _, err := InstallBundle(source, publicKey); err == nil || !strings.Contains(err.Error(), "older than the installed serial") {
+		t.Errorf("InstallBundle() of an older serial error = %v", err)
+	}
+}
+
+func TestBundleUpdateKeepsLocalSettings(t *testing.T) {
+	tests := []struct {
+		name             string
+		extra            string
+		wantTokenStorage string
+		wantWarning      string
+	}{
+		{name: "bundle without storage", wantTokenStorage: TokenStorageEncrypted},
+		{name: "bundle with another storage", extra: "token_storage: plaintext", wantTokenStorage: TokenStorageEncrypted, wantWarning: "the local storage was kept"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := isolatePaths(t)
+			publicKey, privateKey := testBundleKeys(t)
+			source := filepath.Join(directory, "platform.bundle")
+
+			writeTestFile(t, source, string(signTestBundle(t, privateKey, 1, "sensitive: [{role: \"*Owner*\"}]")))
+			if _, err := InstallBundle(source, publicKey); err != nil {
+				t.Fatal(err)
+			}
+
+			err := updateInternalConfigFile(func(configFile *ConfigFile) error {
+				work := configFile.Configs["work"]
+				work.Sensitive = append(work.Sensitive, SensitiveRule{Role: "*Admin*"})
+				work.TokenStorage = TokenStorageEncrypted
+				work.Browser = "firefox"
+				work.Profiles["local"] = &Profile{Name: "local", Region: "eu-west-1"}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			writeTestFile(t, source, string(signTestBundle(t, privateKey, 2, test.extra)))
+			result, err := UpdateBundle()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			configFile, err := ReadInternalConfigFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			work := configFile.Configs["work"]
+
+			if work.TokenStorage != test.wantTokenStorage {
+				t.Errorf("token storage = %q, want %q", work.TokenStorage, test.wantTokenStorage)
+			}
+			if work.Browser != "firefox" {
+				t.Errorf("browser = %q, want the local one", work.Browser)
+			}
+			if work.MatchingSensitiveRule("123456789012", "prod", "Admin") == nil {
+				t.Error("the local sensitive rule was dropped")
+			}
+			if _, ok := work.Profiles["local"]; !ok {
+				t.Error("the local profile was dropped")
+			}
+
+			warnings := strings.Join(result.Warnings, "\n")
+			if test.wantWarning != "" && !strings.Contains(warnings, test.wantWarning) {
+				t.Errorf("warnings = %q, want %q", warnings, test.wantWarning)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+)
+
+type ConfigFormat string
+
+const (
+	ConfigFormatYaml ConfigFormat = "yaml"
+	ConfigFormatJson ConfigFormat = "json"
+	ConfigFormatToml ConfigFormat = "toml"
+)
+
+// ConfigFormatFor returns the format named by format, or the one matching the extension of fileName when format is
+// empty. YAML is the default.
+func ConfigFormatFor(format string, fileName string) (ConfigFormat, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+	}
+
+	switch format {
+	case "", "yaml", "yml", "-":
+		return ConfigFormatYaml, nil
+	case "json":
+		return ConfigFormatJson, nil
+	case "toml":
+		return ConfigFormatToml, nil
+	}
+
+	return "", fmt.Errorf("unknown config format \"%s\". valid values are yaml, json and toml", format)
+}
+
+// encodeConfigFile converts YAML content into format. The YAML keys are kept, so all formats share one schema.
+func encodeConfigFile(content []byte, format ConfigFormat) ([]byte, error) {
+	if format == ConfigFormatYaml {
+		return content, nil
+	}
+
+	document := make(map[string]any)
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case ConfigFormatJson:
+		encoded, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(encoded, '\n'), nil
+	case ConfigFormatToml:
+		var encoded bytes.Buffer
+		if err := toml.NewEncoder(&encoded).Encode(document); err != nil {
+			return nil, err
+		}
+		return encoded.Bytes(), nil
+	}
+
+	return nil, fmt.Errorf("unknown config format \"%s\"", format)
+}
+
+// decodeConfigFile converts content in format into YAML.
+func decodeConfigFile(content []byte, format ConfigFormat) ([]byte, error) {
+	if format == ConfigFormatYaml {
+		return content, nil
+	}
+
+	document := make(map[string]any)
+	switch format {
+	case ConfigFormatJson:
+		if err := json.Unmarshal(content, &document); err != nil {
+			return nil, err
+		}
+	case ConfigFormatToml:
+		if err := toml.Unmarshal(content, &document); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format \"%s\"", format)
+	}
+
+	return yaml.Marshal(normalizeNumbers(document))
+}
+
+// normalizeNumbers turns the float64 and int64 values produced by the JSON and TOML decoders into ints where they are
+// whole numbers, so they decode into the int fields of the config structs.
+func normalizeNumbers(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, item := range typed {
+			typed[key] = normalizeNumbers(item)
+		}
+	case []any:
+		for i, item := range typed {
+			typed[i] = normalizeNumbers(item)
+		}
+	case float64:
+		if typed == float64(int(typed)) {
+			return int(typed)
+		}
+	case int64:
+		return int(typed)
+	}
+
+	return value
+}
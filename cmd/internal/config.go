@@ -1,27 +1,47 @@
 package internal
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	ssoTypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/vahid-haghighat/awsx/utilities"
 	"github.com/vahid-haghighat/awsx/version"
-	"gopkg.in/ini.v1"
 	"gopkg.in/yaml.v3"
+	"net/url"
 	"os"
-	"path"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 )
 
 type Profile struct {
-	Region string `yaml:"region"`
-	Name   string `yaml:"-"`
+	Region    string            `yaml:"region,omitempty"`
+	AccountId string            `yaml:"account_id,omitempty"`
+	Role      string            `yaml:"role,omitempty"`
+	Output    string            `yaml:"output,omitempty"`
+	Settings  map[string]string `yaml:"settings,omitempty"`
+	Name      string            `yaml:"-"`
+}
+
+// defaultProfileOutput is written when a profile does not choose an output format
+const defaultProfileOutput = "json"
+
+// Pinned tells whether the profile always uses the same account and role instead of prompting for them
+func (p *Profile) Pinned() bool {
+	return p.AccountId != "" && p.Role != ""
 }
 
 type Config struct {
-	Id                    string              `yaml:"Id"`
-	Profiles              map[string]*Profile `yaml:"profiles"`
-	LastUsedAccountsCount int                 `yaml:"last_used_accounts_count"`
-	SsoRegion             string              `yaml:"sso_region"`
+	Id                    string              `yaml:"Id,omitempty"`
+	Profiles              map[string]*Profile `yaml:"profiles,omitempty"`
+	LastUsedAccountsCount int                 `yaml:"last_used_accounts_count,omitempty"`
+	SsoRegion             string              `yaml:"sso_region,omitempty"`
+	TokenStorage          string              `yaml:"token_storage,omitempty"`
+	Browser               string              `yaml:"browser,omitempty"`
+	Extends               string              `yaml:"extends,omitempty"`
+	Sensitive             []SensitiveRule     `yaml:"sensitive,omitempty"`
 	Complete              bool                `yaml:"-"`
 }
 
@@ -30,8 +50,14 @@ func (c *Config) GetStartUrl() string {
 }
 
 type ConfigFile struct {
-	Version string             `yaml:"version"`
-	Configs map[string]*Config `yaml:"configs"`
+	Version        string             `yaml:"version"`
+	SchemaVersion  int                `yaml:"schema_version"`
+	TokenStorage   string             `yaml:"token_storage,omitempty"`
+	Selector       string             `yaml:"selector,omitempty"`
+	CurrentContext string             `yaml:"current_context,omitempty"`
+	Defaults       *Config            `yaml:"defaults,omitempty"`
+	Bundle         *InstalledBundle   `yaml:"bundle,omitempty"`
+	Configs        map[string]*Config `yaml:"configs"`
 }
 
 type ClientInformation struct {
@@ -47,39 +73,37 @@ type ClientInformation struct {
 
 type ClientInformationFile struct {
 	Version           string                        `yaml:"version"`
+	SchemaVersion     int                           `yaml:"schema_version"`
 	ClientInformation map[string]*ClientInformation `yaml:"client_information"`
 }
 
 type LastUsageInformation struct {
-	AccountId   string `yaml:"account_id"`
-	AccountName string `yaml:"account_name"`
-	Role        string `yaml:"role"`
+	AccountId   string    `yaml:"account_id"`
+	AccountName string    `yaml:"account_name"`
+	Role        string    `yaml:"role"`
+	Profile     string    `yaml:"profile,omitempty"`
+	UseCount    int       `yaml:"use_count"`
+	LastUsedAt  time.Time `yaml:"last_used_at,omitempty"`
 }
 
 type LastUsageInformationFile struct {
 	Version              string                            `yaml:"version"`
+	SchemaVersion        int                               `yaml:"schema_version"`
 	LastUsageInformation map[string][]LastUsageInformation `yaml:"last_usage_information"`
 }
 
-var home, _ = os.UserHomeDir()
-var defaultAwsCredentialsPath = path.Join(home, ".aws")
-var defaultAwsCredentialsFileName = "credentials"
-
-var defaultInternalPath = path.Join(home, ".config/awsx")
-var defaultConfigFileName = path.Join(defaultInternalPath, "config")
-
-var defaultCachePath = path.Join(defaultInternalPath, "cache")
-var defaultClientInformationFileName = path.Join(defaultCachePath, "access-token")
-var defaultLastUsageFileName = path.Join(defaultCachePath, "last-usage")
-
 func ReadUsageInformationFile() (*LastUsageInformationFile, error) {
-	file, err := os.ReadFile(defaultLastUsageFileName)
-	if err != nil {
+	file, err := readMigratedFile(lastUsageFileName(), lastUsageFileSchema)
+	if errors.Is(err, os.ErrNotExist) {
 		return &LastUsageInformationFile{
 			Version:              version.Version,
+			SchemaVersion:        lastUsageFileSchema.current(),
 			LastUsageInformation: make(map[string][]LastUsageInformation),
 		}, err
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	lastUsageInformationFile := &LastUsageInformationFile{}
 	err = yaml.Unmarshal(file, &lastUsageInformationFile)
@@ -87,6 +111,10 @@ func ReadUsageInformationFile() (*LastUsageInformationFile, error) {
 		return nil, err
 	}
 
+	if lastUsageInformationFile.LastUsageInformation == nil {
+		lastUsageInformationFile.LastUsageInformation = make(map[string][]LastUsageInformation)
+	}
+
 	return lastUsageInformationFile, nil
 }
 
@@ -105,39 +133,58 @@ func GetUsageInformationForConfig(configName string) ([]LastUsageInformation, er
 }
 
 func SetUsageInformationForConfig(configName string, information *LastUsageInformation) error {
-	err := os.MkdirAll(defaultCachePath, 0700)
-	if err != nil {
+	return withFileLock(lastUsageFileName(), func() error {
+		return setUsageInformationForConfig(configName, information)
+	})
+}
+
+func setUsageInformationForConfig(configName string, information *LastUsageInformation) error {
+	usageInformationFile, err := ReadUsageInformationFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
-
-	usageInformationFile, _ := ReadUsageInformationFile()
 	usageInformation, _ := usageInformationFile.LastUsageInformation[configName]
 
-	allUsageInformation := append([]LastUsageInformation{*information}, usageInformation...)
-	var unique []LastUsageInformation
-	uniqueMap := make(map[LastUsageInformation]bool)
-
-	for _, value := range allUsageInformation {
-		if _, exists := uniqueMap[value]; !exists {
-			uniqueMap[value] = true
-			unique = append(unique, value)
+	// the history is kept most recent first, with one entry per account and role
+	used := *information
+	used.UseCount = 1
+	used.LastUsedAt = time.Now().UTC()
+	unique := []LastUsageInformation{used}
+	for _, value := range usageInformation {
+		if value.AccountId == used.AccountId && value.Role == used.Role {
+			unique[0].UseCount += value.UseCount
+			continue
 		}
+		unique = append(unique, value)
+	}
+
+	if len(unique) > maxUsageHistorySize {
+		unique = unique[:maxUsageHistorySize]
 	}
 
 	usageInformationFile.LastUsageInformation[configName] = unique
+	usageInformationFile.Version = version.Version
+	usageInformationFile.SchemaVersion = lastUsageFileSchema.current()
 	content, err := yaml.Marshal(usageInformationFile)
+	if err != nil {
+		return err
+	}
 
-	return os.WriteFile(defaultLastUsageFileName, content, 0700)
+	return writeFileAtomic(lastUsageFileName(), content, 0600)
 }
 
 func ReadClientInformationFile() (*ClientInformationFile, error) {
-	file, err := os.ReadFile(defaultClientInformationFileName)
-	if err != nil {
+	file, err := readMigratedFile(clientInformationFileName(), clientInformationFileSchema)
+	if errors.Is(err, os.ErrNotExist) {
 		return &ClientInformationFile{
 			Version:           version.Version,
+			SchemaVersion:     clientInformationFileSchema.current(),
 			ClientInformation: make(map[string]*ClientInformation),
 		}, nil
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	clientInformationFile := ClientInformationFile{}
 	err = yaml.Unmarshal(file, &clientInformationFile)
@@ -154,38 +201,25 @@ func GetClientInformationForConfig(configName string) (*ClientInformation, error
 		ClientSecretExpiresAt: time.Now().AddDate(-1, 0, 0),
 	}
 
-	clientInformationFile, err := ReadClientInformationFile()
-	if err != nil {
+	// a storage that fails must be reported, a new login would not fix it
+	clientInformation, err := loadClientInformation(configName)
+	if errors.Is(err, ErrClientInformationNotFound) || errors.Is(err, os.ErrNotExist) {
 		return emptyClientInformation, nil
 	}
-
-	clientInformation, exists := clientInformationFile.ClientInformation[configName]
-	if !exists {
-		return emptyClientInformation, nil
+	if err != nil {
+		return nil, err
 	}
 
 	return clientInformation, nil
 }
 
 func SetClientInformationForConfig(configName string, clientInformation *ClientInformation) error {
-	err := os.MkdirAll(defaultCachePath, 0700)
+	storage, err := tokenStorageForConfig(configName)
 	if err != nil {
 		return err
 	}
 
-	existingClientInformationFile, err := ReadClientInformationFile()
-	if err != nil {
-		return err
-	}
-
-	existingClientInformationFile.ClientInformation[configName] = clientInformation
-
-	content, err := yaml.Marshal(existingClientInformationFile)
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(defaultClientInformationFileName, content, 0700)
+	return storage.Save(configName, clientInformation)
 }
 
 func formatExpiration(roleCredentials *ssoTypes.RoleCredentials) string {
@@ -198,116 +232,349 @@ func formatExpiration(roleCredentials *ssoTypes.RoleCredentials) string {
 	return expirationString
 }
 
-func WriteAwsConfigFile(profile string, configuration *Config, credentials *ssoTypes.RoleCredentials) error {
-	if _, exists := configuration.Profiles[profile]; !exists {
+// WriteAwsConfigFile writes the credentials of the profile to the credentials file, and its region, output and settings
+// to its section of the AWS config file, which is the only place the AWS CLI reads them from.
+func WriteAwsConfigFile(profile *Profile, credentials *ssoTypes.RoleCredentials) error {
+	if profile == nil || profile.Name == "" {
 		return errors.New("profile does not exist in the configuration")
 	}
 
-	if configuration.Profiles[profile].Region == "" {
+	if profile.Region == "" {
 		return errors.New("region does not exist in the configuration")
 	}
 
-	file, err := os.OpenFile(path.Join(defaultAwsCredentialsPath, defaultAwsCredentialsFileName), os.O_CREATE, 0644)
+	awsConfigFileName := awsConfigFilePath()
+	err := withFileLock(awsConfigFileName, func() error {
+		return writeAwsProfileSettings(awsConfigFileName, profile)
+	})
 	if err != nil {
 		return err
 	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
 
-	awsCredentialsFile, err := ini.Load(path.Join(defaultAwsCredentialsPath, defaultAwsCredentialsFileName))
+	credentialsFileName := awsCredentialsFilePath()
+	return withFileLock(credentialsFileName, func() error {
+		return writeAwsConfigFile(credentialsFileName, profile, credentials)
+	})
+}
+
+func writeAwsConfigFile(credentialsFileName string, profile *Profile, credentials *ssoTypes.RoleCredentials) error {
+	awsCredentialsFile, err := loadAwsCredentialsFile(credentialsFileName)
 	if err != nil {
 		return err
 	}
 
-	profileSection := awsCredentialsFile.Section(profile)
-	if profileSection == nil {
-		profileSection, _ = awsCredentialsFile.NewSection(profile)
-		_, _ = profileSection.NewKey("aws_access_key_id", *credentials.AccessKeyId)
-		_, _ = profileSection.NewKey("aws_secret_access_key", *credentials.SecretAccessKey)
-		_, _ = profileSection.NewKey("aws_session_token", *credentials.SessionToken)
-		_, _ = profileSection.NewKey("output", "json")
-		_, _ = profileSection.NewKey("region", configuration.Profiles[profile].Region)
-		_, _ = profileSection.NewKey("aws_expiration", formatExpiration(credentials))
-	} else {
-		profileSection.Key("aws_access_key_id").SetValue(*credentials.AccessKeyId)
-		profileSection.Key("aws_secret_access_key").SetValue(*credentials.SecretAccessKey)
-		profileSection.Key("aws_session_token").SetValue(*credentials.SessionToken)
-		profileSection.Key("output").SetValue("json")
-		profileSection.Key("region").SetValue(configuration.Profiles[profile].Region)
-		profileSection.Key("aws_expiration").SetValue(formatExpiration(credentials))
-	}
-
-	err = awsCredentialsFile.SaveTo(path.Join(defaultAwsCredentialsPath, defaultAwsCredentialsFileName))
+	values := map[string]string{
+		"aws_access_key_id":     *credentials.AccessKeyId,
+		"aws_secret_access_key": *credentials.SecretAccessKey,
+		"aws_session_token":     *credentials.SessionToken,
+		"aws_expiration":        formatExpiration(credentials),
+	}
+
+	// sections written by hand get the credentials but are never marked as managed, so their other keys are kept
+	managed := true
+	if existing, err := awsCredentialsFile.GetSection(profile.Name); err == nil {
+		managed = existing.HasKey(awsManagedKey) && existing.Key(awsManagedKey).String() == "true"
+	}
+
+	profileSection := awsCredentialsFile.Section(profile.Name)
+	if managed {
+		values[awsManagedKey] = "true"
+		// the region, output and settings written by earlier versions now live in the AWS config file
+		for _, key := range profileSection.KeyStrings() {
+			if _, ok := values[key]; !ok {
+				profileSection.DeleteKey(key)
+			}
+		}
+	}
+
+	for _, key := range []string{"aws_access_key_id", "aws_secret_access_key", "aws_session_token", "aws_expiration", awsManagedKey} {
+		if value, ok := values[key]; ok {
+			profileSection.Key(key).SetValue(value)
+		}
+	}
+
+	return saveAwsCredentialsFile(credentialsFileName, awsCredentialsFile)
+}
+
+// writeAwsProfileSettings writes the region, output and settings of the profile to its section of the AWS config file.
+// Sections awsx did not create, such as SSO profiles maintained by hand, get the values but are not marked as managed,
+// so their other keys are never removed.
+func writeAwsProfileSettings(awsConfigFileName string, profile *Profile) error {
+	awsConfigFile, err := loadAwsCredentialsFile(awsConfigFileName)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	output := profile.Output
+	if output == "" {
+		output = defaultProfileOutput
+	}
+
+	values := map[string]string{
+		"output": output,
+		"region": profile.Region,
+	}
+	settingKeys := utilities.Keys(profile.Settings)
+	sort.Strings(settingKeys)
+	nestedValues := make(map[string][]string)
+	for _, settingKey := range settingKeys {
+		// dotted keys such as s3.max_concurrent_requests are written as AWS nested values
+		if parent, child, nested := strings.Cut(settingKey, "."); nested {
+			nestedValues[parent] = append(nestedValues[parent], child+" = "+profile.Settings[settingKey])
+			values[parent] = ""
+			continue
+		}
+		values[settingKey] = profile.Settings[settingKey]
+	}
+
+	sectionName := awsConfigProfilePrefix + profile.Name
+	if profile.Name == "default" {
+		sectionName = profile.Name
+	}
+
+	managed := true
+	if existing, err := awsConfigFile.GetSection(sectionName); err == nil {
+		managed = existing.HasKey(awsManagedKey) && existing.Key(awsManagedKey).String() == "true"
+	}
+
+	profileSection := awsConfigFile.Section(sectionName)
+	if managed {
+		values[awsManagedKey] = "true"
+		// settings removed from the profile since the last refresh must not linger in the section
+		for _, key := range profileSection.KeyStrings() {
+			if _, ok := values[key]; !ok {
+				profileSection.DeleteKey(key)
+			}
+		}
+	}
+
+	for _, key := range []string{"output", "region", awsManagedKey} {
+		if value, ok := values[key]; ok {
+			profileSection.Key(key).SetValue(value)
+			delete(values, key)
+		}
+	}
+
+	settingKeys = utilities.Keys(values)
+	sort.Strings(settingKeys)
+	for _, key := range settingKeys {
+		// recreate the key, so nested values from an earlier refresh are replaced instead of appended to
+		profileSection.DeleteKey(key)
+		settingKey, err := profileSection.NewKey(key, values[key])
+		if err != nil {
+			return err
+		}
+		for _, nestedValue := range nestedValues[key] {
+			if err = settingKey.AddNestedValue(nestedValue); err != nil {
+				return err
+			}
+		}
+	}
+
+	// most refreshes change nothing here, the file and its backups are only touched when they do
+	var content bytes.Buffer
+	if _, err = awsConfigFile.WriteTo(&content); err != nil {
+		return err
+	}
+	if current, err := os.ReadFile(awsConfigFileName); err == nil && bytes.Equal(current, content.Bytes()) {
+		return nil
+	}
+
+	return saveAwsFile(awsConfigFileName, awsConfigBackupPrefix, awsConfigFile)
 }
 
+// ReadInternalConfig returns the configs with the values they inherit filled in. Use ReadInternalConfigFile to change
+// configs, so inherited values are not copied into them.
 func ReadInternalConfig() (map[string]*Config, error) {
-	file, err := os.ReadFile(defaultConfigFileName)
+	configFile, err := ReadInternalConfigFile()
 	if err != nil {
-		return make(map[string]*Config), err
+		if configFile != nil {
+			return configFile.Configs, err
+		}
+		return nil, err
+	}
+
+	return configFile.ResolveConfigs()
+}
+
+func ReadInternalConfigFile() (*ConfigFile, error) {
+	file, err := readMigratedFile(configFileName(), configFileSchema)
+	if errors.Is(err, os.ErrNotExist) {
+		return &ConfigFile{
+			Version:       version.Version,
+			SchemaVersion: configFileSchema.current(),
+			Configs:       make(map[string]*Config),
+		}, err
+	}
+	if err != nil {
+		return nil, invalidConfigFileError(err)
 	}
 
 	configFile := ConfigFile{}
 	err = yaml.Unmarshal(file, &configFile)
 	if err != nil {
-		return nil, err
+		return nil, invalidConfigFileError(err)
+	}
+
+	if configFile.Configs == nil {
+		configFile.Configs = make(map[string]*Config)
 	}
 
 	for _, config := range configFile.Configs {
 		config.Complete = true
+		if config.Profiles == nil {
+			config.Profiles = make(map[string]*Profile)
+		}
 		for name, profile := range config.Profiles {
 			profile.Name = name
 		}
 	}
 
-	return configFile.Configs, nil
+	return &configFile, nil
 }
 
-func ExportInternalConfig(exportPath string) error {
-	file, err := os.ReadFile(defaultConfigFileName)
+// invalidConfigFileError points to "awsx config edit" when the config file cannot be parsed
+func invalidConfigFileError(err error) error {
+	var typeError *yaml.TypeError
+	if errors.As(err, &typeError) || strings.HasPrefix(err.Error(), "yaml:") {
+		return fmt.Errorf("failed to read %s: %w. fix it with \"awsx config edit\"", configFileName(), err)
+	}
+
+	return err
+}
+
+// ExportInternalConfig encodes the named configs, or all of them when configNames is empty, in format.
+func ExportInternalConfig(configNames []string, format ConfigFormat) ([]byte, error) {
+	configFile, err := ReadInternalConfigFile()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	configFile := ConfigFile{}
-	err = yaml.Unmarshal(file, &configFile)
+	// configs exported by name are resolved, so they do not depend on configs or defaults left behind
+	if len(configNames) > 0 {
+		selected := make(map[string]*Config)
+		for _, configName := range configNames {
+			config, ok := configFile.Configs[configName]
+			if !ok {
+				return nil, fmt.Errorf("config \"%s\" does not exist", configName)
+			}
+
+			selected[configName], err = configFile.ResolveConfig(configName, config)
+			if err != nil {
+				return nil, err
+			}
+		}
+		configFile.Configs = selected
+		configFile.Defaults = nil
+		configFile.CurrentContext = ""
+		configFile.Bundle = nil
+	}
+
+	content, err := yaml.Marshal(configFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return os.WriteFile(exportPath, file, 0700)
+	return encodeConfigFile(content, format)
 }
 
-func ImportInternalConfig(importPath string) error {
-	file, err := os.ReadFile(importPath)
+// ImportInternalConfig decodes and validates configs exported by ExportInternalConfig. Nothing is written, the result is
+// meant for MergeImportedConfigs and WriteInternalConfig.
+func ImportInternalConfig(content []byte, format ConfigFormat) (map[string]*Config, error) {
+	content, err := decodeConfigFile(content, format)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	content, _, err = migrateContent(content, configFileSchema)
+	if err != nil {
+		return nil, err
 	}
 
 	configFile := ConfigFile{}
-	err = yaml.Unmarshal(file, &configFile)
+	err = yaml.Unmarshal(content, &configFile)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	for configName, config := range configFile.Configs {
+		if config == nil {
+			delete(configFile.Configs, configName)
+		}
 	}
 
-	return WriteInternalConfig(configFile.Configs)
+	// imported configs are resolved against the defaults and configs of the imported file, not the local ones
+	configs, err := configFile.ResolveConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range configs {
+		config.Complete = true
+	}
+
+	if err = ValidateConfigs(configs); err != nil {
+		return nil, fmt.Errorf("the imported configs are invalid:\n%w", err)
+	}
+
+	if len(configs) == 0 {
+		return nil, errors.New("no configs to import")
+	}
+
+	return configs, nil
 }
 
-func WriteInternalConfig(input map[string]*Config) error {
-	err := os.MkdirAll(defaultInternalPath, 0700)
+// DiffInternalConfigs returns the line changes between two sets of configs as they would be written to the config file.
+func DiffInternalConfigs(before map[string]*Config, after map[string]*Config) (string, error) {
+	beforeContent, err := yaml.Marshal(before)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	existingConfigs, _ := ReadInternalConfig()
-	if existingConfigs == nil {
-		existingConfigs = make(map[string]*Config)
+	afterContent, err := yaml.Marshal(after)
+	if err != nil {
+		return "", err
+	}
+
+	lines := diffLines(splitLines(string(beforeContent)), splitLines(string(afterContent)))
+
+	// unchanged lines are only shown close to a change, they tell which config and profile a change belongs to
+	const context = 3
+	var output strings.Builder
+	skipped := false
+	for i, line := range lines {
+		changed := false
+		for j := max(0, i-context); j < min(len(lines), i+context+1); j++ {
+			if !strings.HasPrefix(lines[j], " ") {
+				changed = true
+				break
+			}
+		}
+
+		if !changed {
+			skipped = true
+			continue
+		}
+
+		if skipped && output.Len() > 0 {
+			output.WriteString("...\n")
+		}
+		skipped = false
+		output.WriteString(line + "\n")
 	}
 
+	return output.String(), nil
+}
+
+func WriteInternalConfig(input map[string]*Config) error {
+	return updateInternalConfigFile(func(configFile *ConfigFile) error {
+		configFile.Configs = mergeCompleteConfigs(configFile.Configs, input)
+		return nil
+	})
+}
+
+// mergeCompleteConfigs returns the configs of input, taking the stored version of the ones that are not complete.
+func mergeCompleteConfigs(existingConfigs map[string]*Config, input map[string]*Config) map[string]*Config {
 	configs := make(map[string]*Config)
 	for key, value := range input {
 		if value.Complete {
@@ -320,31 +587,141 @@ func WriteInternalConfig(input map[string]*Config) error {
 		}
 	}
 
-	config, err := yaml.Marshal(ConfigFile{
-		Version: version.Version,
-		Configs: configs,
+	return configs
+}
+
+// updateInternalConfigFile applies update to the config file while holding its lock.
+func updateInternalConfigFile(update func(configFile *ConfigFile) error) error {
+	return withFileLock(configFileName(), func() error {
+		configFile, err := ReadInternalConfigFile()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		err = update(configFile)
+		if err != nil {
+			return err
+		}
+
+		configFile.Version = version.Version
+		configFile.SchemaVersion = configFileSchema.current()
+		return writeInternalConfigFile(configFile)
 	})
+}
+
+func writeInternalConfigFile(configFile *ConfigFile) error {
+	config, err := yaml.Marshal(configFile)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(defaultConfigFileName, config, 0700)
+	return writeFileAtomic(configFileName(), config, 0600)
+}
+
+// UpdateInternalConfig applies update to a single config while holding the config file lock. Missing configs are
+// created when create is set.
+func UpdateInternalConfig(configName string, create bool, update func(config *Config) error) error {
+	return updateInternalConfigFile(func(configFile *ConfigFile) error {
+		config, ok := configFile.Configs[configName]
+		if !ok && !create {
+			return fmt.Errorf("config \"%s\" does not exist", configName)
+		} else if !ok {
+			config = &Config{
+				Profiles: make(map[string]*Profile),
+			}
+		}
+
+		if config.Profiles == nil {
+			config.Profiles = make(map[string]*Profile)
+		}
+
+		err := update(config)
+		if err != nil {
+			return err
+		}
+
+		resolved, err := configFile.ResolveConfig(configName, config)
+		if err != nil {
+			return err
+		}
+
+		// new configs remember one role unless they inherit a history size
+		if !ok && resolved.LastUsedAccountsCount == 0 {
+			config.LastUsedAccountsCount = 1
+			resolved.LastUsedAccountsCount = 1
+		}
+
+		if err = resolved.Validate(); err != nil {
+			return fmt.Errorf("config \"%s\" is invalid:\n%w", configName, err)
+		}
+
+		config.Complete = true
+		configFile.Configs[configName] = config
+
+		// a removed profile can no longer be the current one
+		if context, err := ParseConfigContext(configFile.CurrentContext); err == nil && context.Config == configName {
+			if _, ok := config.Profiles[context.Profile]; context.Profile != "" && !ok {
+				configFile.CurrentContext = configName
+			}
+		}
+		return nil
+	})
+}
+
+// StartUrlId accepts either the id of an AWS access portal or its start URL and returns the id.
+func StartUrlId(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if !strings.Contains(input, "://") {
+		return input, nil
+	}
+
+	startUrl, err := url.Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("invalid start URL \"%s\": %w", input, err)
+	}
+
+	id, found := strings.CutSuffix(startUrl.Hostname(), ".awsapps.com")
+	if !found || id == "" || strings.Contains(id, ".") {
+		return "", fmt.Errorf("invalid start URL \"%s\". expected https://<id>.awsapps.com/start", input)
+	}
+
+	return id, nil
 }
 
 func RemoveInternalConfig(configNames []string) error {
-	configs, _ := ReadInternalConfig()
+	remaining := 0
+	err := updateInternalConfigFile(func(configFile *ConfigFile) error {
+		for _, configName := range configNames {
+			for _, inheriting := range configFile.InheritingConfigs(configName) {
+				if !slices.Contains(configNames, inheriting) {
+					return fmt.Errorf("config \"%s\" cannot be removed, config \"%s\" extends it", configName, inheriting)
+				}
+			}
+		}
 
-	for _, configName := range configNames {
-		if _, ok := configs[configName]; !ok {
-			continue
+		for _, configName := range configNames {
+			delete(configFile.Configs, configName)
+		}
+
+		if context, err := ParseConfigContext(configFile.CurrentContext); err == nil {
+			if _, ok := configFile.Configs[context.Config]; !ok {
+				configFile.CurrentContext = ""
+			}
 		}
 
-		delete(configs, configName)
+		remaining = len(configFile.Configs)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if len(configs) == 0 {
-		return os.RemoveAll(defaultInternalPath)
+	if remaining == 0 {
+		if err = os.Remove(configFileName()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return os.RemoveAll(cachePath())
 	}
 
-	return WriteInternalConfig(configs)
+	return nil
 }
@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"gopkg.in/ini.v1"
+)
+
+// isolatePaths points every file awsx reads or writes into a temporary directory and returns it.
+func isolatePaths(t *testing.T) string {
+	t.Helper()
+
+	directory := t.TempDir()
+	t.Setenv("HOME", directory)
+	t.Setenv(awsxHomeEnvironmentVariable, filepath.Join(directory, "awsx"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(directory, "aws", "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(directory, "aws", "credentials"))
+	t.Setenv(cachePassphraseEnvironmentVariable, "")
+	t.Setenv(selectorEnvironmentVariable, "")
+
+	return directory
+}
+
+func writeTestFile(t *testing.T, fileName string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileName, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testRoleCredentials(accessKeyId string) *ssoTypes.RoleCredentials {
+	secret, token := "secret", "token"
+	return &ssoTypes.RoleCredentials{AccessKeyId: &accessKeyId, SecretAccessKey: &secret, SessionToken: &token, Expiration: 1700000000000}
+}
+
+func loadTestIni(t *testing.T, fileName string) *ini.File {
+	t.Helper()
+
+	file, err := ini.LoadSources(awsCredentialsLoadOptions, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestWriteAwsConfigFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials string
+		profiles    []*Profile
+		// keys of the section of the last profile in the credentials file, mapped to their expected value
+		wantCredentials map[string]string
+		// keys that must not be in that section
+		wantMissing []string
+		// keys of the section of the last profile in the AWS config file
+		wantSettings map[string]string
+	}{
+		{
+			name:            "creates a managed section",
+			profiles:        []*Profile{{Name: "dev", Region: "eu-west-1"}},
+			wantCredentials: map[string]string{"aws_access_key_id": "key-1", awsManagedKey: "true", "aws_expiration": "2023-11-14T22:13:20Z"},
+			wantMissing:     []string{"region", "output"},
+			wantSettings:    map[string]string{"region": "eu-west-1", "output": "json", awsManagedKey: "true"},
+		},
+		{
+			name:            "keeps the keys of a section written by hand",
+			credentials:     "[dev]\nfoo = bar\n",
+			profiles:        []*Profile{{Name: "dev", Region: "eu-west-1"}, {Name: "dev", Region: "eu-west-1"}},
+			wantCredentials: map[string]string{"aws_access_key_id": "key-2", "foo": "bar"},
+			wantMissing:     []string{awsManagedKey},
+		},
+		{
+			name:            "moves the settings of earlier versions out of managed sections",
+			credentials:     "[dev]\naws_access_key_id = old\nregion = eu-central-1\noutput = text\ncli_pager =\nawsx_managed = true\n",
+			profiles:        []*Profile{{Name: "dev", Region: "eu-west-1"}},
+			wantCredentials: map[string]string{"aws_access_key_id": "key-1", awsManagedKey: "true"},
+			wantMissing:     []string{"region", "output", "cli_pager"},
+		},
+		{
+			name: "replaces removed settings and nested values",
+			profiles: []*Profile{
+				{Name: "dev", Region: "eu-west-1", Settings: map[string]string{"cli_pager": "", "s3.max_concurrent_requests": "20"}},
+				{Name: "dev", Region: "eu-west-1", Output: "text", Settings: map[string]string{"s3.max_queue_size": "100"}},
+			},
+			wantSettings: map[string]string{"output": "text", "s3": "\nmax_queue_size = 100"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isolatePaths(t)
+			if test.credentials != "" {
+				writeTestFile(t, awsCredentialsFilePath(), test.credentials)
+			}
+
+			for index, profile := range test.profiles {
+				if err := WriteAwsConfigFile(profile, testRoleCredentials("key-"+strconv.Itoa(index+1))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			last := test.profiles[len(test.profiles)-1]
+			credentialsSection := loadTestIni(t, awsCredentialsFilePath()).Section(last.Name)
+			for key, want := range test.wantCredentials {
+				if got := credentialsSection.Key(key).String(); got != want {
+					t.Errorf("credentials key %s = %q, want %q", key, got, want)
+				}
+			}
+			for _, key := range test.wantMissing {
+				if credentialsSection.HasKey(key) {
+					t.Errorf("credentials key %s is still set", key)
+				}
+			}
+
+			settingsSection := loadTestIni(t, awsConfigFilePath()).Section(awsConfigProfilePrefix + last.Name)
+			for key, want := range test.wantSettings {
+				got := settingsSection.Key(key).String()
+				if nested := settingsSection.Key(key).NestedValues(); len(nested) > 0 {
+					got = "\n" + strings.Join(nested, "\n")
+				}
+				if got != want {
+					t.Errorf("config key %s = %q, want %q", key, got, want)
+				}
+			}
+			if len(test.wantSettings) > 0 && settingsSection.HasKey("cli_pager") {
+				t.Error("config key cli_pager was not removed")
+			}
+		})
+	}
+}
+
+func TestWriteAwsConfigFileKeepsUnmanagedProfiles(t *testing.T) {
+	isolatePaths(t)
+	writeTestFile(t, awsConfigFilePath(), "[profile dev]\nsso_start_url = https://d-1234567890.awsapps.com/start\nsso_region = eu-west-1\n")
+
+	for i := 0; i < 2; i++ {
+		if err := WriteAwsConfigFile(&Profile{Name: "dev", Region: "us-east-1"}, testRoleCredentials("key")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	section := loadTestIni(t, awsConfigFilePath()).Section("profile dev")
+	if section.Key("sso_start_url").String() == "" || section.Key("region").String() != "us-east-1" {
+		t.Errorf("unexpected section %v", section.KeysHash())
+	}
+	if section.HasKey(awsManagedKey) {
+		t.Error("a profile written by hand was marked as managed")
+	}
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultConfigName is used when neither an argument, a project file nor the current context names a config
+const DefaultConfigName = "default"
+
+// ConfigContext names the config, and optionally the profile, commands use when no config is given
+type ConfigContext struct {
+	Config  string
+	Profile string
+}
+
+func (c ConfigContext) String() string {
+	if c.Profile == "" {
+		return c.Config
+	}
+
+	return c.Config + "/" + c.Profile
+}
+
+// ParseConfigContext parses "<config>" or "<config>/<profile>".
+func ParseConfigContext(value string) (ConfigContext, error) {
+	configName, profileName, _ := strings.Cut(strings.TrimSpace(value), "/")
+	if configName == "" {
+		return ConfigContext{}, fmt.Errorf("invalid context \"%s\". expected <config> or <config>/<profile>", value)
+	}
+
+	return ConfigContext{Config: configName, Profile: profileName}, nil
+}
+
+// CurrentConfigContext returns the context stored by UseConfigContext, or the default config when none is stored.
+func CurrentConfigContext() (ConfigContext, error) {
+	configFile, err := ReadInternalConfigFile()
+	if errors.Is(err, os.ErrNotExist) {
+		return ConfigContext{Config: DefaultConfigName}, nil
+	}
+	if err != nil {
+		return ConfigContext{}, err
+	}
+
+	if configFile.CurrentContext == "" {
+		return ConfigContext{Config: DefaultConfigName}, nil
+	}
+
+	return ParseConfigContext(configFile.CurrentContext)
+}
+
+// UseConfigContext stores context as the current context after checking that its config and profile exist.
+func UseConfigContext(context ConfigContext) error {
+	return updateInternalConfigFile(func(configFile *ConfigFile) error {
+		config, ok := configFile.Configs[context.Config]
+		if !ok {
+			return fmt.Errorf("config \"%s\" does not exist", context.Config)
+		}
+
+		if _, ok = config.Profiles[context.Profile]; context.Profile != "" && !ok {
+			return fmt.Errorf("profile \"%s\" does not exist in config \"%s\"", context.Profile, context.Config)
+		}
+
+		configFile.CurrentContext = context.String()
+		return nil
+	})
+}
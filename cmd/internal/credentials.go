@@ -0,0 +1,346 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/ini.v1"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// awsManagedKey marks the sections of the credentials and AWS config files written by awsx
+const awsManagedKey = "awsx_managed"
+const awsCredentialsBackupPrefix = "credentials-"
+const awsConfigBackupPrefix = "config-"
+const awsCredentialsBackupTimeFormat = "20060102T150405.000000000Z"
+const awsCredentialsBackupRetention = 20
+
+// values containing '#' or ';' and quoted values are common in hand maintained files and must survive a rewrite
+var awsCredentialsLoadOptions = ini.LoadOptions{
+	IgnoreInlineComment:     true,
+	PreserveSurroundedQuote: true,
+	AllowNestedValues:       true,
+}
+
+var awsSecretKeys = []string{"aws_secret_access_key", "aws_session_token"}
+var awsCredentialKeys = []string{"aws_access_key_id", "aws_secret_access_key", "aws_session_token", "aws_expiration"}
+
+func init() {
+	// keep the original spacing of unrelated keys instead of aligning every section
+	ini.PrettyFormat = false
+	ini.PrettyEqual = true
+}
+
+type PrunedProfile struct {
+	Name      string
+	ExpiredAt time.Time
+}
+
+type AwsCredentialsBackup struct {
+	Name      string
+	CreatedAt time.Time
+}
+
+func loadAwsCredentialsFile(credentialsFileName string) (*ini.File, error) {
+	if _, err := os.Stat(credentialsFileName); errors.Is(err, os.ErrNotExist) {
+		return ini.Empty(awsCredentialsLoadOptions), nil
+	}
+
+	return ini.LoadSources(awsCredentialsLoadOptions, credentialsFileName)
+}
+
+// saveAwsCredentialsFile backs up the current credentials file and replaces it. The caller holds the file lock.
+func saveAwsCredentialsFile(credentialsFileName string, awsCredentialsFile *ini.File) error {
+	return saveAwsFile(credentialsFileName, awsCredentialsBackupPrefix, awsCredentialsFile)
+}
+
+// saveAwsFile backs up the current file under the backup prefix and replaces it. The caller holds the file lock.
+func saveAwsFile(fileName string, backupPrefix string, file *ini.File) error {
+	var content bytes.Buffer
+	if _, err := file.WriteTo(&content); err != nil {
+		return err
+	}
+
+	if err := backupAwsFile(fileName, backupPrefix); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", fileName, err)
+	}
+
+	return writeFileAtomic(fileName, content.Bytes(), 0600)
+}
+
+func backupAwsCredentialsFile(credentialsFileName string) error {
+	return backupAwsFile(credentialsFileName, awsCredentialsBackupPrefix)
+}
+
+func backupAwsFile(fileName string, backupPrefix string) error {
+	content, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	backups, err := listAwsFileBackups(backupPrefix)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) > 0 {
+		latest, err := os.ReadFile(path.Join(backupPath(), backups[0].Name))
+		if err == nil && bytes.Equal(latest, content) {
+			return nil
+		}
+	}
+
+	name := backupPrefix + time.Now().UTC().Format(awsCredentialsBackupTimeFormat)
+	err = writeFileAtomic(path.Join(backupPath(), name), content, 0600)
+	if err != nil {
+		return err
+	}
+
+	backups = append([]AwsCredentialsBackup{{Name: name}}, backups...)
+	for _, backup := range backups[min(len(backups), awsCredentialsBackupRetention):] {
+		if err = os.Remove(path.Join(backupPath(), backup.Name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PruneAwsCredentials removes the sections written by awsx whose credentials expired more than olderThan ago. With
+// blank, only the credential keys of those sections are removed. With dryRun, the file is left untouched.
+func PruneAwsCredentials(olderThan time.Duration, blank bool, dryRun bool) ([]PrunedProfile, error) {
+	var pruned []PrunedProfile
+
+	credentialsFileName := awsCredentialsFilePath()
+	err := withFileLock(credentialsFileName, func() error {
+		awsCredentialsFile, err := loadAwsCredentialsFile(credentialsFileName)
+		if err != nil {
+			return err
+		}
+
+		cutoff := time.Now().Add(-olderThan)
+		for _, section := range awsCredentialsFile.Sections() {
+			if !section.HasKey("aws_expiration") {
+				continue
+			}
+
+			expiredAt, err := time.Parse(time.RFC3339, section.Key("aws_expiration").String())
+			if err != nil || expiredAt.After(cutoff) {
+				continue
+			}
+
+			pruned = append(pruned, PrunedProfile{Name: section.Name(), ExpiredAt: expiredAt})
+			if dryRun {
+				continue
+			}
+
+			if blank {
+				for _, key := range awsCredentialKeys {
+					section.DeleteKey(key)
+				}
+			} else {
+				awsCredentialsFile.DeleteSection(section.Name())
+			}
+		}
+
+		if dryRun || len(pruned) == 0 {
+			return nil
+		}
+
+		return saveAwsCredentialsFile(credentialsFileName, awsCredentialsFile)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pruned, nil
+}
+
+// ListAwsCredentialsBackups returns the backups of the credentials file, newest first.
+func ListAwsCredentialsBackups() ([]AwsCredentialsBackup, error) {
+	return listAwsFileBackups(awsCredentialsBackupPrefix)
+}
+
+func listAwsFileBackups(backupPrefix string) ([]AwsCredentialsBackup, error) {
+	files, err := filepath.Glob(path.Join(backupPath(), backupPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []AwsCredentialsBackup
+	for _, file := range files {
+		name := path.Base(file)
+		createdAt, err := time.Parse(awsCredentialsBackupTimeFormat, strings.TrimPrefix(name, backupPrefix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, AwsCredentialsBackup{Name: name, CreatedAt: createdAt})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+func resolveAwsCredentialsBackup(name string) (string, error) {
+	if name == "" {
+		backups, err := ListAwsCredentialsBackups()
+		if err != nil {
+			return "", err
+		}
+		if len(backups) == 0 {
+			return "", errors.New("no backups of the credentials file found")
+		}
+		name = backups[0].Name
+	}
+
+	backupFileName := path.Join(backupPath(), path.Base(name))
+	if _, err := os.Stat(backupFileName); err != nil {
+		return "", fmt.Errorf("backup \"%s\" does not exist", name)
+	}
+
+	return backupFileName, nil
+}
+
+// RestoreAwsCredentialsBackup replaces the credentials file with a backup, the latest one when name is empty.
+// The replaced file is backed up as well, so a restore can be undone.
+func RestoreAwsCredentialsBackup(name string) (string, error) {
+	backupFileName, err := resolveAwsCredentialsBackup(name)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(backupFileName)
+	if err != nil {
+		return "", err
+	}
+
+	credentialsFileName := awsCredentialsFilePath()
+	err = withFileLock(credentialsFileName, func() error {
+		if err := backupAwsCredentialsFile(credentialsFileName); err != nil {
+			return err
+		}
+
+		return writeFileAtomic(credentialsFileName, content, 0600)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return path.Base(backupFileName), nil
+}
+
+// DiffAwsCredentialsBackup returns the line changes from a backup, the latest one when name is empty, to the current
+// credentials file. Secret values are masked unless showSecrets is set.
+func DiffAwsCredentialsBackup(name string, showSecrets bool) (string, error) {
+	backupFileName, err := resolveAwsCredentialsBackup(name)
+	if err != nil {
+		return "", err
+	}
+
+	backup, err := os.ReadFile(backupFileName)
+	if err != nil {
+		return "", err
+	}
+
+	current, err := os.ReadFile(awsCredentialsFilePath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	before, after := splitLines(string(backup)), splitLines(string(current))
+	if !showSecrets {
+		before, after = maskSecretLines(before), maskSecretLines(after)
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", backupFileName, awsCredentialsFilePath()))
+	for _, line := range diffLines(before, after) {
+		output.WriteString(line + "\n")
+	}
+
+	return output.String(), nil
+}
+
+func splitLines(content string) []string {
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return nil
+	}
+
+	return strings.Split(content, "\n")
+}
+
+func maskSecretLines(lines []string) []string {
+	masked := make([]string, len(lines))
+	for i, line := range lines {
+		masked[i] = line
+		key, _, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+
+		for _, secretKey := range awsSecretKeys {
+			if strings.TrimSpace(key) == secretKey {
+				masked[i] = strings.TrimRight(key, " \t") + " = ****"
+			}
+		}
+	}
+
+	return masked
+}
+
+// diffLines prefixes removed lines with "-", added lines with "+" and unchanged lines with " ", based on the longest
+// common subsequence of both sides.
+func diffLines(before []string, after []string) []string {
+	lengths := make([][]int, len(before)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(after)+1)
+	}
+
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			lines = append(lines, " "+before[i])
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			lines = append(lines, "-"+before[i])
+			i++
+		default:
+			lines = append(lines, "+"+after[j])
+			j++
+		}
+	}
+
+	for ; i < len(before); i++ {
+		lines = append(lines, "-"+before[i])
+	}
+	for ; j < len(after); j++ {
+		lines = append(lines, "+"+after[j])
+	}
+
+	return lines
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []string
+	}{
+		{name: "both empty"},
+		{name: "added file", after: "a\nb", want: []string{"+a", "+b"}},
+		{name: "removed file", before: "a\nb", want: []string{"-a", "-b"}},
+		{name: "unchanged", before: "a\nb", after: "a\nb", want: []string{" a", " b"}},
+		{name: "changed line", before: "a\nb\nc", after: "a\nx\nc", want: []string{" a", "-b", "+x", " c"}},
+		{name: "inserted line", before: "a\nc", after: "a\nb\nc", want: []string{" a", "+b", " c"}},
+		{name: "removed section", before: "[dev]\nk = 1\n[prod]\nk = 2", after: "[prod]\nk = 2", want: []string{"-[dev]", "-k = 1", " [prod]", " k = 2"}},
+		{name: "appended", before: "a", after: "a\nb", want: []string{" a", "+b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := diffLines(splitLines(test.before), splitLines(test.after))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("diffLines() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMaskSecretLines(t *testing.T) {
+	lines := []string{"[dev]", "aws_access_key_id = AKIA", "aws_secret_access_key=secret", "aws_session_token = token", "note = aws_session_token"}
+	want := []string{"[dev]", "aws_access_key_id = AKIA", "aws_secret_access_key = ****", "aws_session_token = ****", "note = aws_session_token"}
+
+	if got := maskSecretLines(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("maskSecretLines() = %q, want %q", got, want)
+	}
+}
+
+func TestDiffAwsCredentialsBackup(t *testing.T) {
+	isolatePaths(t)
+	writeTestFile(t, awsCredentialsFilePath(), "[dev]\naws_session_token = old\n")
+	if err := backupAwsCredentialsFile(awsCredentialsFilePath()); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, awsCredentialsFilePath(), "[dev]\naws_session_token = new\n")
+
+	diff, err := DiffAwsCredentialsBackup("", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(diff, "old") || strings.Contains(diff, "new") {
+		t.Errorf("the diff shows secrets:\n%s", diff)
+	}
+
+	if diff, err = DiffAwsCredentialsBackup("", true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "-aws_session_token = old") || !strings.Contains(diff, "+aws_session_token = new") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
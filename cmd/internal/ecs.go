@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const ecsCredentialsPath = "/creds"
+
+type ecsCredentials struct {
+	AccessKeyId     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+	Expiration      string `json:"Expiration"`
+	RoleArn         string `json:"RoleArn"`
+}
+
+type EcsServerInformation struct {
+	Address            string `yaml:"address"`
+	AuthorizationToken string `yaml:"authorization_token"`
+	AccountId          string `yaml:"account_id"`
+	RoleName           string `yaml:"role_name"`
+}
+
+// NewEcsHandler serves credentials following the ECS container credentials protocol used with AWS_CONTAINER_CREDENTIALS_FULL_URI.
+func NewEcsHandler(provider *RoleCredentialsProvider, authorizationToken string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ecsCredentialsPath, func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(request.Header.Get("Authorization")), []byte(authorizationToken)) != 1 {
+			http.Error(writer, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		credentials, err := provider.Retrieve()
+		if err != nil {
+			log.Printf("Failed to retrieve credentials: %s", err)
+			http.Error(writer, "failed to retrieve credentials", http.StatusInternalServerError)
+			return
+		}
+
+		writeJson(writer, ecsCredentials{
+			AccessKeyId:     *credentials.AccessKeyId,
+			SecretAccessKey: *credentials.SecretAccessKey,
+			Token:           *credentials.SessionToken,
+			Expiration:      formatExpiration(credentials),
+			RoleArn:         fmt.Sprintf("arn:aws:iam::%s:role/%s", provider.AccountId, provider.RoleName),
+		})
+	})
+	return mux
+}
+
+func ServeEcs(address string, provider *RoleCredentialsProvider) error {
+	authorizationToken, err := newRandomToken()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	information := &EcsServerInformation{
+		Address:            listener.Addr().String(),
+		AuthorizationToken: authorizationToken,
+		AccountId:          provider.AccountId,
+		RoleName:           provider.RoleName,
+	}
+	if err = writeEcsServerInformation(information); err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	log.Printf("Serving container credentials on http://%s%s", information.Address, ecsCredentialsPath)
+	log.Printf("Run \"awsx serve docker-flags\" to print the matching docker run flags")
+	return http.Serve(listener, NewEcsHandler(provider, authorizationToken))
+}
+
+func ReadEcsServerInformation() (*EcsServerInformation, error) {
+	file, err := os.ReadFile(ecsServerFileName())
+	if err != nil {
+		return nil, errors.New("no container credentials server found. run \"awsx serve --ecs\" first")
+	}
+
+	information := &EcsServerInformation{}
+	err = yaml.Unmarshal(file, information)
+	if err != nil {
+		return nil, err
+	}
+
+	return information, nil
+}
+
+// DockerFlags returns the docker run flags that point the AWS SDKs inside a container at the credentials server.
+// The SDKs only accept plain http for loopback hosts, so unless another host is given the container shares the host network.
+func (i *EcsServerInformation) DockerFlags(host string) []string {
+	_, port, _ := net.SplitHostPort(i.Address)
+
+	var flags []string
+	if host == "" {
+		host = "127.0.0.1"
+		flags = append(flags, "--network", "host")
+	}
+
+	fullUri := fmt.Sprintf("http://%s%s", net.JoinHostPort(host, port), ecsCredentialsPath)
+	return append(flags,
+		"-e", "AWS_CONTAINER_CREDENTIALS_FULL_URI="+fullUri,
+		"-e", "AWS_CONTAINER_AUTHORIZATION_TOKEN="+i.AuthorizationToken,
+	)
+}
+
+func writeEcsServerInformation(information *EcsServerInformation) error {
+	content, err := yaml.Marshal(information)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(ecsServerFileName(), content, 0600)
+}
+
+func FormatShellFlags(flags []string) string {
+	var quoted []string
+	for _, flag := range flags {
+		quoted = append(quoted, shellQuote(flag))
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/vahid-haghighat/awsx/version"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// editorErrorPrefix marks the comments awsx adds to the top of a file it reopens, so they can be removed again
+const editorErrorPrefix = "# awsx: "
+
+// ReadInternalConfigContent returns the config file as it is stored, or an empty config file when there is none yet.
+func ReadInternalConfigContent() ([]byte, error) {
+	content, err := os.ReadFile(configFileName())
+	if errors.Is(err, os.ErrNotExist) {
+		return yaml.Marshal(&ConfigFile{
+			Version:       version.Version,
+			SchemaVersion: configFileSchema.current(),
+			Configs:       make(map[string]*Config),
+		})
+	}
+
+	return content, err
+}
+
+// ValidateInternalConfigContent checks content against the schema of the config file without writing it. Unknown keys,
+// broken inheritance and invalid configs are all reported.
+func ValidateInternalConfigContent(content []byte) error {
+	migrated, _, err := migrateContent(content, configFileSchema)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(migrated))
+	decoder.KnownFields(true)
+
+	configFile := ConfigFile{}
+	if err = decoder.Decode(&configFile); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	for configName, config := range configFile.Configs {
+		if config == nil {
+			return fmt.Errorf("config \"%s\" is empty", configName)
+		}
+	}
+
+	configs, err := configFile.ResolveConfigs()
+	if err != nil {
+		return err
+	}
+
+	var problems []error
+	if err = ValidateConfigs(configs); err != nil {
+		problems = append(problems, err)
+	}
+
+	if configFile.CurrentContext != "" {
+		context, err := ParseConfigContext(configFile.CurrentContext)
+		if err != nil {
+			problems = append(problems, err)
+		} else if config, ok := configs[context.Config]; !ok {
+			problems = append(problems, fmt.Errorf("current context: config \"%s\" does not exist", context.Config))
+		} else if _, ok = config.Profiles[context.Profile]; context.Profile != "" && !ok {
+			problems = append(problems, fmt.Errorf("current context: profile \"%s\" does not exist in config \"%s\"", context.Profile, context.Config))
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// WriteEditedInternalConfig replaces the config file with content, keeping its comments and layout. It fails when the
+// file changed since original was read, so edits made by another awsx process are not lost.
+func WriteEditedInternalConfig(original []byte, content []byte) error {
+	if err := ValidateInternalConfigContent(content); err != nil {
+		return err
+	}
+
+	return withFileLock(configFileName(), func() error {
+		current, err := ReadInternalConfigContent()
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(current, original) {
+			return errors.New("the config file was changed by another process while it was being edited. please edit it again")
+		}
+
+		return writeFileAtomic(configFileName(), content, 0600)
+	})
+}
+
+// EditInEditor writes content to a temporary file, opens it in the editor of the user and returns the edited content.
+// The editor is taken from $VISUAL or $EDITOR and may include arguments, for example "code --wait".
+func EditInEditor(content []byte, pattern string) ([]byte, error) {
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(editorCommand())
+	command := exec.Command(fields[0], append(fields[1:], file.Name())...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err = command.Run(); err != nil {
+		return nil, fmt.Errorf("editor \"%s\" failed: %w", strings.Join(fields, " "), err)
+	}
+
+	return os.ReadFile(file.Name())
+}
+
+func editorCommand() string {
+	for _, variable := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(variable)); editor != "" {
+			return editor
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+
+	return "vi"
+}
+
+// yaml reports its errors with the line they were found on, for example "yaml: line 3: did not find expected key"
+var editorLineErrorPattern = regexp.MustCompile(`^(?:yaml: )?\s*line ([0-9]+): (.*)$`)
+
+// AddEditorErrors puts the problems of err as comments into content, replacing the comments of an earlier attempt.
+// Problems with a line number are put above that line, all others on top of the file.
+func AddEditorErrors(content []byte, err error) []byte {
+	lines := strings.Split(string(RemoveEditorErrors(content)), "\n")
+
+	var problems []string
+	lineProblems := make(map[int][]string)
+	for _, problem := range strings.Split(err.Error(), "\n") {
+		problem = strings.ReplaceAll(problem, "internal.", "")
+		if strings.HasSuffix(problem, "unmarshal errors:") {
+			continue
+		}
+
+		match := editorLineErrorPattern.FindStringSubmatch(problem)
+		if match == nil {
+			problems = append(problems, problem)
+			continue
+		}
+
+		line, _ := strconv.Atoi(match[1])
+		if line < 1 || line > len(lines) {
+			problems = append(problems, problem)
+			continue
+		}
+		lineProblems[line] = append(lineProblems[line], match[2])
+	}
+
+	var result strings.Builder
+	result.WriteString(editorErrorPrefix + "the edited file is invalid. fix the problems below, or remove everything to cancel\n")
+	for _, problem := range problems {
+		result.WriteString(editorErrorPrefix + problem + "\n")
+	}
+	result.WriteString(editorErrorPrefix + "\n")
+
+	for index, line := range lines {
+		indentation := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		for _, problem := range lineProblems[index+1] {
+			result.WriteString(indentation + editorErrorPrefix + problem + "\n")
+		}
+		result.WriteString(line)
+		if index < len(lines)-1 {
+			result.WriteString("\n")
+		}
+	}
+
+	return []byte(result.String())
+}
+
+// RemoveEditorErrors removes the comments added by AddEditorErrors.
+func RemoveEditorErrors(content []byte) []byte {
+	lines := strings.SplitAfter(string(content), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		// editors may strip the trailing space of the empty comment lines
+		if !strings.HasPrefix(strings.TrimSpace(line)+" ", editorErrorPrefix) {
+			kept = append(kept, line)
+		}
+	}
+
+	return []byte(strings.Join(kept, ""))
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/vahid-haghighat/awsx/version"
+	"gopkg.in/yaml.v3"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// maxUsageHistorySize bounds the entries kept per config, the least recently used ones are dropped first
+const maxUsageHistorySize = 100
+
+// frecencyHalfLife is the time after which a use counts half as much
+const frecencyHalfLife = 7 * 24 * time.Hour
+
+// Frecency scores an entry by how often and how recently it was used. Entries migrated from a history without
+// timestamps only count their uses.
+func (l LastUsageInformation) Frecency(now time.Time) float64 {
+	if l.LastUsedAt.IsZero() {
+		return float64(l.UseCount) * math.Pow(0.5, 8)
+	}
+
+	age := now.Sub(l.LastUsedAt)
+	if age < 0 {
+		age = 0
+	}
+
+	return float64(l.UseCount) * math.Pow(0.5, float64(age)/float64(frecencyHalfLife))
+}
+
+// SortByFrecency orders history by frecency, keeping the order of use between equal scores.
+func SortByFrecency(history []LastUsageInformation) []LastUsageInformation {
+	now := time.Now()
+	sorted := append([]LastUsageInformation(nil), history...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Frecency(now) > sorted[j].Frecency(now)
+	})
+
+	return sorted
+}
+
+// accountFrecencies sums the frecency of the roles used in every account of history
+func accountFrecencies(history []LastUsageInformation) map[string]float64 {
+	now := time.Now()
+	scores := make(map[string]float64)
+	for _, entry := range history {
+		scores[entry.AccountId] += entry.Frecency(now)
+	}
+
+	return scores
+}
+
+// roleFrecencies returns the frecency of the roles used in accountId
+func roleFrecencies(history []LastUsageInformation, accountId string) map[string]float64 {
+	now := time.Now()
+	scores := make(map[string]float64)
+	for _, entry := range history {
+		if entry.AccountId == accountId {
+			scores[entry.Role] += entry.Frecency(now)
+		}
+	}
+
+	return scores
+}
+
+// ReadUsageHistory returns the usage history of every config, most recently used first.
+func ReadUsageHistory() (map[string][]LastUsageInformation, error) {
+	usageInformationFile, err := ReadUsageInformationFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return usageInformationFile.LastUsageInformation, nil
+}
+
+// ClearUsageInformation removes the history of the named configs, or all of it when none is named.
+func ClearUsageInformation(configNames []string) error {
+	return withFileLock(lastUsageFileName(), func() error {
+		usageInformationFile, err := ReadUsageInformationFile()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if len(configNames) == 0 {
+			usageInformationFile.LastUsageInformation = make(map[string][]LastUsageInformation)
+		}
+		for _, configName := range configNames {
+			if _, ok := usageInformationFile.LastUsageInformation[configName]; !ok {
+				return fmt.Errorf("there is no history for config \"%s\"", configName)
+			}
+			delete(usageInformationFile.LastUsageInformation, configName)
+		}
+
+		usageInformationFile.Version = version.Version
+		usageInformationFile.SchemaVersion = lastUsageFileSchema.current()
+		content, err := yaml.Marshal(usageInformationFile)
+		if err != nil {
+			return err
+		}
+
+		return writeFileAtomic(lastUsageFileName(), content, 0600)
+	})
+}
+
+// PreviousUsageInformation returns the account and role used before the most recent one, across all configs.
+func PreviousUsageInformation() (string, *LastUsageInformation, error) {
+	history, err := ReadUsageHistory()
+	if err != nil {
+		return "", nil, err
+	}
+
+	type use struct {
+		configName string
+		entry      LastUsageInformation
+	}
+
+	var uses []use
+	for configName, entries := range history {
+		for _, entry := range entries {
+			if !entry.LastUsedAt.IsZero() {
+				uses = append(uses, use{configName: configName, entry: entry})
+			}
+		}
+	}
+
+	if len(uses) < 2 {
+		return "", nil, errors.New("there is no previous account and role in the history yet")
+	}
+
+	sort.Slice(uses, func(i, j int) bool {
+		return uses[i].entry.LastUsedAt.After(uses[j].entry.LastUsedAt)
+	})
+
+	return uses[1].configName, &uses[1].entry, nil
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+var shellHooks = map[string]string{
+	"bash": `_awsx_hook() {
+  [ "$PWD" = "$_AWSX_HOOK_DIR" ] && return
+  _AWSX_HOOK_DIR="$PWD"
+  local profile
+  profile="$(%[1]s project profile 2>/dev/null)"
+  if [ -n "$profile" ]; then
+    export AWS_PROFILE="$profile"
+    _AWSX_HOOK_PROFILE="$profile"
+  elif [ -n "$_AWSX_HOOK_PROFILE" ]; then
+    [ "$AWS_PROFILE" = "$_AWSX_HOOK_PROFILE" ] && unset AWS_PROFILE
+    unset _AWSX_HOOK_PROFILE
+  fi
+}
+case ";$PROMPT_COMMAND;" in
+  *";_awsx_hook;"*) ;;
+  *) PROMPT_COMMAND="_awsx_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`,
+	"zsh": `_awsx_hook() {
+  local profile
+  profile="$(%[1]s project profile 2>/dev/null)"
+  if [[ -n "$profile" ]]; then
+    export AWS_PROFILE="$profile"
+    _AWSX_HOOK_PROFILE="$profile"
+  elif [[ -n "$_AWSX_HOOK_PROFILE" ]]; then
+    [[ "$AWS_PROFILE" = "$_AWSX_HOOK_PROFILE" ]] && unset AWS_PROFILE
+    unset _AWSX_HOOK_PROFILE
+  fi
+}
+autoload -U add-zsh-hook
+add-zsh-hook chpwd _awsx_hook
+_awsx_hook
+`,
+	"fish": `function _awsx_hook --on-variable PWD
+  set -l profile (%[1]s project profile 2>/dev/null)
+  if test -n "$profile"
+    set -gx AWS_PROFILE $profile
+    set -g _AWSX_HOOK_PROFILE $profile
+  else if set -q _AWSX_HOOK_PROFILE
+    test "$AWS_PROFILE" = "$_AWSX_HOOK_PROFILE"; and set -e AWS_PROFILE
+    set -e _AWSX_HOOK_PROFILE
+  end
+end
+_awsx_hook
+`,
+}
+
+// ShellHook returns the code that makes shell switch AWS_PROFILE to the profile of the nearest .awsx.yaml whenever the
+// working directory changes. A profile set by hand is left alone when leaving a project.
+func ShellHook(shell string) (string, error) {
+	hook, ok := shellHooks[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell \"%s\". supported shells are bash, zsh and fish", shell)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	quoted := shellQuote(executable)
+	if shell == "fish" {
+		// fish does not understand the '\'' escape of POSIX shells
+		quoted = "'" + strings.ReplaceAll(strings.ReplaceAll(executable, `\`, `\\`), "'", `\'`) + "'"
+	}
+
+	return fmt.Sprintf(hook, quoted), nil
+}
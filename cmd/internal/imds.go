@@ -0,0 +1,223 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const imdsTokenHeader = "X-aws-ec2-metadata-token"
+const imdsTokenTtlHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+const imdsMaxTokenTtl = 21600
+
+const imdsCredentialsPath = "/latest/meta-data/iam/security-credentials/"
+
+type imdsCredentials struct {
+	Code            string `json:"Code"`
+	LastUpdated     string `json:"LastUpdated"`
+	Type            string `json:"Type"`
+	AccessKeyId     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+	Expiration      string `json:"Expiration"`
+}
+
+type imdsIdentityDocument struct {
+	AccountId string `json:"accountId"`
+	Region    string `json:"region"`
+}
+
+type imdsServer struct {
+	provider *RoleCredentialsProvider
+	region   string
+	mutex    sync.Mutex
+	tokens   map[string]time.Time
+}
+
+// NewImdsHandler serves the subset of the EC2 instance metadata service (IMDSv2) the AWS SDKs use to find credentials and the region.
+func NewImdsHandler(provider *RoleCredentialsProvider, region string) http.Handler {
+	server := &imdsServer{
+		provider: provider,
+		region:   region,
+		tokens:   make(map[string]time.Time),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", server.handleToken)
+	mux.HandleFunc(imdsCredentialsPath, server.authorized(server.handleCredentials))
+	mux.HandleFunc("/latest/meta-data/placement/region", server.authorized(server.handleRegion))
+	mux.HandleFunc("/latest/dynamic/instance-identity/document", server.authorized(server.handleIdentityDocument))
+	return mux
+}
+
+// ServeImds serves the endpoint on address. The endpoint has no authentication beyond its session tokens, anyone who
+// can reach it can read the credentials, so address must be a loopback address unless allowRemote is set.
+func ServeImds(address string, allowRemote bool, provider *RoleCredentialsProvider, region string) error {
+	if err := ValidateImdsAddress(address, allowRemote); err != nil {
+		return err
+	}
+
+	log.Printf("Serving instance metadata credentials on http://%s", address)
+	log.Printf("Set AWS_EC2_METADATA_SERVICE_ENDPOINT=http://%s to use them", address)
+	return http.ListenAndServe(address, restrictHosts(NewImdsHandler(provider, region), allowRemote))
+}
+
+// ValidateImdsAddress fails for addresses other than loopback ones unless allowRemote is set.
+func ValidateImdsAddress(address string, allowRemote bool) error {
+	if !allowRemote && !isLoopbackAddress(address) {
+		return fmt.Errorf("\"%s\" is not a loopback address. anyone who can reach it could read the credentials, pass --allow-remote to listen on it anyway", address)
+	}
+
+	return nil
+}
+
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+
+	return isLoopbackHost(host)
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// restrictHosts rejects requests whose Host header names a domain, so a web page cannot reach the endpoint through DNS
+// rebinding. Only loopback hosts are accepted, or any IP address when the endpoint is served to remote clients.
+func restrictHosts(next http.Handler, allowRemote bool) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		host := request.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+
+		if !isLoopbackHost(host) && (!allowRemote || net.ParseIP(host) == nil) {
+			http.Error(writer, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+func (s *imdsServer) handleToken(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPut {
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if request.Header.Get("X-Forwarded-For") != "" {
+		http.Error(writer, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	ttl, err := strconv.Atoi(request.Header.Get(imdsTokenTtlHeader))
+	if err != nil || ttl < 1 || ttl > imdsMaxTokenTtl {
+		http.Error(writer, "invalid token ttl", http.StatusBadRequest)
+		return
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		http.Error(writer, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	s.mutex.Lock()
+	now := time.Now()
+	for existing, expiresAt := range s.tokens {
+		if expiresAt.Before(now) {
+			delete(s.tokens, existing)
+		}
+	}
+	s.tokens[token] = now.Add(time.Duration(ttl) * time.Second)
+	s.mutex.Unlock()
+
+	writer.Header().Set(imdsTokenTtlHeader, strconv.Itoa(ttl))
+	_, _ = writer.Write([]byte(token))
+}
+
+func (s *imdsServer) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.mutex.Lock()
+		expiresAt, ok := s.tokens[request.Header.Get(imdsTokenHeader)]
+		s.mutex.Unlock()
+
+		if !ok || expiresAt.Before(time.Now()) {
+			http.Error(writer, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(writer, request)
+	}
+}
+
+func (s *imdsServer) handleCredentials(writer http.ResponseWriter, request *http.Request) {
+	roleName := strings.TrimPrefix(request.URL.Path, imdsCredentialsPath)
+	if roleName == "" {
+		_, _ = writer.Write([]byte(s.provider.RoleName))
+		return
+	}
+
+	if roleName != s.provider.RoleName {
+		http.NotFound(writer, request)
+		return
+	}
+
+	credentials, err := s.provider.Retrieve()
+	if err != nil {
+		log.Printf("Failed to retrieve credentials: %s", err)
+		http.Error(writer, "failed to retrieve credentials", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(writer, imdsCredentials{
+		Code:            "Success",
+		LastUpdated:     time.Now().UTC().Format(time.RFC3339),
+		Type:            "AWS-HMAC",
+		AccessKeyId:     *credentials.AccessKeyId,
+		SecretAccessKey: *credentials.SecretAccessKey,
+		Token:           *credentials.SessionToken,
+		Expiration:      formatExpiration(credentials),
+	})
+}
+
+func (s *imdsServer) handleRegion(writer http.ResponseWriter, _ *http.Request) {
+	_, _ = writer.Write([]byte(s.region))
+}
+
+func (s *imdsServer) handleIdentityDocument(writer http.ResponseWriter, _ *http.Request) {
+	writeJson(writer, imdsIdentityDocument{
+		AccountId: s.provider.AccountId,
+		Region:    s.region,
+	})
+}
+
+func writeJson(writer http.ResponseWriter, value any) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		http.Error(writer, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	_, _ = writer.Write(content)
+}
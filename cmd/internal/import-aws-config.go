@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"fmt"
+	"gopkg.in/ini.v1"
+	"os"
+	"strings"
+)
+
+const awsConfigProfilePrefix = "profile "
+const awsConfigSsoSessionPrefix = "sso-session "
+
+// aws-vault lets a profile inherit the keys of another one, chains longer than this are treated as cycles
+const awsConfigMaxIncludeDepth = 10
+
+// awsConfigDialect describes how a tool stores its SSO profiles in the AWS config file
+type awsConfigDialect struct {
+	// keyPrefix is prepended to the sso_* keys, granted writes granted_sso_start_url and friends
+	keyPrefix string
+	// resolveIncludes follows aws-vault's include_profile key
+	resolveIncludes bool
+	// accept filters the profiles to import, all SSO profiles are imported when nil
+	accept func(section *ini.Section) bool
+}
+
+var awsConfigDialects = map[ImportSource]awsConfigDialect{
+	ImportSourceAwsConfig: {},
+	ImportSourceAwsVault: {
+		resolveIncludes: true,
+	},
+	ImportSourceGranted: {
+		keyPrefix: "granted_",
+	},
+	ImportSourceAwsSsoUtil: {
+		accept: func(section *ini.Section) bool {
+			populated, _ := section.Key("sso_auto_populated").Bool()
+			return populated
+		},
+	},
+}
+
+type awsConfigSsoTarget struct {
+	configName string
+	startUrl   string
+	ssoRegion  string
+}
+
+// readAwsConfigSsoConfigs converts the [sso-session] blocks and the SSO profiles of the AWS config file into awsx
+// configs. Profiles that cannot be converted are reported as warnings instead of failing the whole import.
+func readAwsConfigSsoConfigs(dialect awsConfigDialect) (map[string]*Config, []string, error) {
+	awsConfigFileName := awsConfigFilePath()
+	if _, err := os.Stat(awsConfigFileName); err != nil {
+		return nil, nil, err
+	}
+
+	awsConfigFile, err := ini.LoadSources(awsCredentialsLoadOptions, awsConfigFileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	profileSections := make(map[string]*ini.Section)
+	for _, section := range awsConfigFile.Sections() {
+		if profileName, ok := awsConfigProfileName(section.Name()); ok {
+			profileSections[profileName] = section
+		}
+	}
+
+	lookup := func(section *ini.Section, key string) string {
+		for depth := 0; section != nil && depth < awsConfigMaxIncludeDepth; depth++ {
+			if value := section.Key(key).String(); value != "" {
+				return value
+			}
+
+			if !dialect.resolveIncludes {
+				return ""
+			}
+			section = profileSections[section.Key("include_profile").String()]
+		}
+
+		return ""
+	}
+
+	configs := make(map[string]*Config)
+	sessions := make(map[string]awsConfigSsoTarget)
+	var warnings []string
+
+	for _, section := range awsConfigFile.Sections() {
+		sessionName, found := strings.CutPrefix(section.Name(), awsConfigSsoSessionPrefix)
+		if !found {
+			continue
+		}
+
+		target := awsConfigSsoTarget{
+			configName: strings.TrimSpace(sessionName),
+			startUrl:   section.Key("sso_start_url").String(),
+			ssoRegion:  section.Key("sso_region").String(),
+		}
+		if target.startUrl == "" || target.ssoRegion == "" {
+			warnings = append(warnings, fmt.Sprintf("skipped sso-session \"%s\": sso_start_url and sso_region are required", target.configName))
+			continue
+		}
+
+		sessions[target.configName] = target
+	}
+
+	for _, section := range awsConfigFile.Sections() {
+		profileName, ok := awsConfigProfileName(section.Name())
+		if !ok || (dialect.accept != nil && !dialect.accept(section)) {
+			continue
+		}
+
+		var target awsConfigSsoTarget
+		if sessionName := lookup(section, dialect.keyPrefix+"sso_session"); sessionName != "" {
+			target, ok = sessions[sessionName]
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("skipped profile \"%s\": sso-session \"%s\" is not defined", profileName, sessionName))
+				continue
+			}
+		} else if startUrl := lookup(section, dialect.keyPrefix+"sso_start_url"); startUrl != "" {
+			target = awsConfigSsoTarget{
+				startUrl:  startUrl,
+				ssoRegion: lookup(section, dialect.keyPrefix+"sso_region"),
+			}
+			if target.ssoRegion == "" {
+				warnings = append(warnings, fmt.Sprintf("skipped profile \"%s\": %ssso_region is required", profileName, dialect.keyPrefix))
+				continue
+			}
+		} else {
+			continue
+		}
+
+		id, err := StartUrlId(target.startUrl)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipped profile \"%s\": %s", profileName, err))
+			continue
+		}
+
+		// legacy profiles join the config of a session with the same portal, or a config named after the portal
+		if target.configName == "" {
+			target.configName = id
+			for _, session := range sessions {
+				if sessionId, err := StartUrlId(session.startUrl); err == nil && sessionId == id && session.ssoRegion == target.ssoRegion {
+					target.configName = session.configName
+					break
+				}
+			}
+		}
+
+		config, exists := configs[target.configName]
+		if !exists {
+			config = &Config{
+				Id:                    id,
+				SsoRegion:             target.ssoRegion,
+				Profiles:              make(map[string]*Profile),
+				LastUsedAccountsCount: 1,
+				Complete:              true,
+			}
+			configs[target.configName] = config
+		} else if config.Id != id || config.SsoRegion != target.ssoRegion {
+			warnings = append(warnings, fmt.Sprintf("skipped profile \"%s\": config \"%s\" already uses a different start URL or SSO region", profileName, target.configName))
+			continue
+		}
+
+		region := lookup(section, "region")
+		if region == "" {
+			region = target.ssoRegion
+		}
+
+		output := lookup(section, "output")
+		if err := ValidateProfileOutput(output); err != nil {
+			warnings = append(warnings, fmt.Sprintf("ignored the output of profile \"%s\": %s", profileName, err))
+			output = ""
+		}
+
+		config.Profiles[profileName] = &Profile{
+			Region:    region,
+			Name:      profileName,
+			AccountId: lookup(section, dialect.keyPrefix+"sso_account_id"),
+			Role:      lookup(section, dialect.keyPrefix+"sso_role_name"),
+			Output:    output,
+		}
+	}
+
+	return configs, warnings, nil
+}
+
+func awsConfigProfileName(sectionName string) (string, bool) {
+	if sectionName == "default" {
+		return sectionName, true
+	}
+
+	profileName, found := strings.CutPrefix(sectionName, awsConfigProfilePrefix)
+	if !found {
+		return "", false
+	}
+
+	return strings.TrimSpace(profileName), true
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ImportSource string
+
+const (
+	ImportSourceAwsConfig  ImportSource = "aws-config"
+	ImportSourceAwsVault   ImportSource = "aws-vault"
+	ImportSourceGranted    ImportSource = "granted"
+	ImportSourceAwsSsoUtil ImportSource = "aws-sso-util"
+)
+
+var ImportSources = []ImportSource{ImportSourceAwsConfig, ImportSourceAwsVault, ImportSourceGranted, ImportSourceAwsSsoUtil}
+
+type ConflictResolution string
+
+const (
+	ConflictResolutionSkip      ConflictResolution = "skip"
+	ConflictResolutionOverwrite ConflictResolution = "overwrite"
+	ConflictResolutionMerge     ConflictResolution = "merge"
+)
+
+// ReadImportSource reads the SSO configuration of another credential tool and converts it into awsx configs. The
+// returned warnings describe the profiles that could not be converted.
+func ReadImportSource(source ImportSource) (map[string]*Config, []string, error) {
+	dialect, ok := awsConfigDialects[source]
+	if !ok {
+		var names []string
+		for _, importSource := range ImportSources {
+			names = append(names, string(importSource))
+		}
+		return nil, nil, fmt.Errorf("unknown import source \"%s\". supported sources are %s", source, strings.Join(names, ", "))
+	}
+
+	configs, warnings, err := readAwsConfigSsoConfigs(dialect)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(configs) == 0 {
+		return nil, warnings, fmt.Errorf("no SSO profiles for %s found in %s", source, awsConfigFilePath())
+	}
+
+	return configs, warnings, nil
+}
+
+// ImportConflicts returns the names of the imported configs that already exist, sorted.
+func ImportConflicts(existing map[string]*Config, imported map[string]*Config) []string {
+	var conflicts []string
+	for configName := range imported {
+		if _, ok := existing[configName]; ok {
+			conflicts = append(conflicts, configName)
+		}
+	}
+	sort.Strings(conflicts)
+
+	return conflicts
+}
+
+// MergeImportedConfigs returns the configs of configFile together with the imported ones. Conflicting configs are
+// resolved with resolutions, a conflict without a resolution is an error.
+func MergeImportedConfigs(configFile *ConfigFile, imported map[string]*Config, resolutions map[string]ConflictResolution) (map[string]*Config, error) {
+	if err := ValidateConfigs(imported); err != nil {
+		return nil, fmt.Errorf("the imported configs are invalid:\n%w", err)
+	}
+
+	merged := make(map[string]*Config)
+	for configName, config := range configFile.Configs {
+		merged[configName] = config
+	}
+
+	var unresolved []string
+	for configName, config := range imported {
+		config.Complete = true
+
+		current, exists := merged[configName]
+		if !exists {
+			merged[configName] = config
+			continue
+		}
+
+		switch resolutions[configName] {
+		case ConflictResolutionSkip:
+		case ConflictResolutionOverwrite:
+			merged[configName] = config
+		case ConflictResolutionMerge:
+			// imported configs arrive resolved, stored ones may inherit their start URL and SSO region
+			resolved, err := configFile.ResolveConfig(configName, current)
+			if err != nil {
+				return nil, err
+			}
+
+			if resolved.Id != config.Id || resolved.SsoRegion != config.SsoRegion {
+				return nil, fmt.Errorf("cannot merge config \"%s\": the start URL or SSO region differ", configName)
+			}
+
+			for profileName, profile := range config.Profiles {
+				current.Profiles[profileName] = profile
+			}
+		default:
+			unresolved = append(unresolved, configName)
+		}
+	}
+
+	if len(unresolved) > 0 {
+		sort.Strings(unresolved)
+		return nil, errors.New("configs already exist: " + strings.Join(unresolved, ", "))
+	}
+
+	return merged, nil
+}
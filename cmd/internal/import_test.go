@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/vahid-haghighat/awsx/utilities"
+)
+
+func testConfig(id string, profileNames ...string) *Config {
+	config := &Config{Id: id, SsoRegion: "eu-west-1", LastUsedAccountsCount: 3, Profiles: make(map[string]*Profile)}
+	for _, profileName := range profileNames {
+		config.Profiles[profileName] = &Profile{Name: profileName, Region: "eu-west-1"}
+	}
+
+	return config
+}
+
+func TestMergeImportedConfigs(t *testing.T) {
+	tests := []struct {
+		name        string
+		configFile  *ConfigFile
+		imported    map[string]*Config
+		resolutions map[string]ConflictResolution
+		// wantProfiles maps the configs of the result to their sorted profile names
+		wantProfiles map[string]string
+		wantId       string
+		wantErr      string
+	}{
+		{
+			name:         "new configs are added",
+			configFile:   &ConfigFile{Configs: map[string]*Config{"work": testConfig("d-1111111111", "dev")}},
+			imported:     map[string]*Config{"home": testConfig("d-2222222222", "default")},
+			wantProfiles: map[string]string{"work": "dev", "home": "default"},
+		},
+		{
+			name:         "skip keeps the stored config",
+			configFile:   &ConfigFile{Configs: map[string]*Config{"work": testConfig("d-1111111111", "dev")}},
+			imported:     map[string]*Config{"work": testConfig("d-2222222222", "prod")},
+			resolutions:  map[string]ConflictResolution{"work": ConflictResolutionSkip},
+			wantProfiles: map[string]string{"work": "dev"},
+			wantId:       "d-1111111111",
+		},
+		{
+			name:         "overwrite replaces the stored config",
+			configFile:   &ConfigFile{Configs: map[string]*Config{"work": testConfig("d-1111111111", "dev")}},
+			imported:     map[string]*Config{"work": testConfig("d-2222222222", "prod")},
+			resolutions:  map[string]ConflictResolution{"work": ConflictResolutionOverwrite},
+			wantProfiles: map[string]string{"work": "prod"},
+			wantId:       "d-2222222222",
+		},
+		{
+			name:         "merge adds the imported profiles",
+			configFile:   &ConfigFile{Configs: map[string]*Config{"work": testConfig("d-1111111111", "dev")}},
+			imported:     map[string]*Config{"work": testConfig("d-1111111111", "prod")},
+			resolutions:  map[string]ConflictResolution{"work": ConflictResolutionMerge},
+			wantProfiles: map[string]string{"work": "dev,prod"},
+			wantId:       "d-1111111111",
+		},
+		{
+			name: "merge compares against the inherited start URL",
+			configFile: &ConfigFile{
+				Defaults: &Config{Id: "d-1111111111", SsoRegion: "eu-west-1"},
+				Configs:  map[string]*Config{"work": {LastUsedAccountsCount: 3, Profiles: map[string]*Profile{"dev": {Name: "dev", Region: "eu-west-1"}}}},
+			},
+			imported:     map[string]*Config{"work": testConfig("d-1111111111", "prod")},
+			resolutions:  map[string]ConflictResolution{"work": ConflictResolutionMerge},
+			wantProfiles: map[string]string{"work": "dev,prod"},
+		},
+		{
+			name:        "merge refuses another start URL",
+			configFile:  &ConfigFile{Configs: map[string]*Config{"work": testConfig("d-1111111111", "dev")}},
+			imported:    map[string]*Config{"work": testConfig("d-2222222222", "prod")},
+			resolutions: map[string]ConflictResolution{"work": ConflictResolutionMerge},
+			wantErr:     "the start URL or SSO region differ",
+		},
+		{
+			name:       "conflicts need a resolution",
+			configFile: &ConfigFile{Configs: map[string]*Config{"work": testConfig("d-1111111111", "dev"), "home": testConfig("d-2222222222", "dev")}},
+			imported:   map[string]*Config{"work": testConfig("d-1111111111", "prod"), "home": testConfig("d-2222222222", "prod")},
+			wantErr:    "configs already exist: home, work",
+		},
+		{
+			name:       "invalid imported configs",
+			configFile: &ConfigFile{Configs: map[string]*Config{}},
+			imported:   map[string]*Config{"work": {Id: "d-1111111111", SsoRegion: "nowhere-1", LastUsedAccountsCount: 1}},
+			wantErr:    "the imported configs are invalid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, err := MergeImportedConfigs(test.configFile, test.imported, test.resolutions)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("MergeImportedConfigs() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(merged) != len(test.wantProfiles) {
+				t.Errorf("MergeImportedConfigs() returned %d configs, want %d", len(merged), len(test.wantProfiles))
+			}
+			for configName, wantProfiles := range test.wantProfiles {
+				config, ok := merged[configName]
+				if !ok {
+					t.Errorf("config %s is missing", configName)
+					continue
+				}
+
+				profileNames := utilities.Keys(config.Profiles)
+				sort.Strings(profileNames)
+				if got := strings.Join(profileNames, ","); got != wantProfiles {
+					t.Errorf("profiles of %s = %s, want %s", configName, got, wantProfiles)
+				}
+			}
+
+			if test.wantId != "" && merged["work"].Id != test.wantId {
+				t.Errorf("id of work = %s, want %s", merged["work"].Id, test.wantId)
+			}
+		})
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	existing := map[string]*Config{"work": {}, "home": {}}
+	imported := map[string]*Config{"work": {}, "home": {}, "lab": {}}
+
+	if got := strings.Join(ImportConflicts(existing, imported), ","); got != "home,work" {
+		t.Errorf("ImportConflicts() = %s, want home,work", got)
+	}
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ResolveConfigs returns every config of the file with the values it inherits filled in. A config inherits from the
+// config it extends, or from the defaults block when it extends nothing.
+func (f *ConfigFile) ResolveConfigs() (map[string]*Config, error) {
+	resolved := make(map[string]*Config)
+	for configName, config := range f.Configs {
+		resolvedConfig, err := f.ResolveConfig(configName, config)
+		if err != nil {
+			return nil, err
+		}
+		resolved[configName] = resolvedConfig
+	}
+
+	return resolved, nil
+}
+
+// ResolveConfig returns a copy of config, which does not have to be part of the file yet, with the values it inherits
+// filled in.
+func (f *ConfigFile) ResolveConfig(configName string, config *Config) (*Config, error) {
+	parent, err := f.Parent(configName, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeConfig(parent, config), nil
+}
+
+// Parent returns the resolved config that config inherits from, nil when it inherits nothing.
+func (f *ConfigFile) Parent(configName string, config *Config) (*Config, error) {
+	return f.parent(configName, configName, config, map[string]bool{configName: true})
+}
+
+func (f *ConfigFile) parent(rootName string, configName string, config *Config, visited map[string]bool) (*Config, error) {
+	if config.Extends == "" {
+		return f.Defaults, nil
+	}
+
+	if visited[config.Extends] {
+		return nil, fmt.Errorf("config \"%s\" inherits from itself through \"%s\"", rootName, configName)
+	}
+	visited[config.Extends] = true
+
+	base, ok := f.Configs[config.Extends]
+	if !ok || base == nil {
+		return nil, fmt.Errorf("config \"%s\" extends \"%s\", which does not exist", configName, config.Extends)
+	}
+
+	parent, err := f.parent(rootName, config.Extends, base, visited)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeConfig(parent, base), nil
+}
+
+// mergeConfig returns a copy of child with the empty values taken from parent. Profiles are merged by name.
+func mergeConfig(parent *Config, child *Config) *Config {
+	merged := *child
+	merged.Extends = ""
+	merged.Profiles = make(map[string]*Profile)
+
+	if parent != nil {
+		if merged.Id == "" {
+			merged.Id = parent.Id
+		}
+		if merged.SsoRegion == "" {
+			merged.SsoRegion = parent.SsoRegion
+		}
+		if merged.LastUsedAccountsCount == 0 {
+			merged.LastUsedAccountsCount = parent.LastUsedAccountsCount
+		}
+		if merged.TokenStorage == "" {
+			merged.TokenStorage = parent.TokenStorage
+		}
+		if merged.Browser == "" {
+			merged.Browser = parent.Browser
+		}
+
+		// guard rails only add up, a config cannot lift the ones it inherits
+		merged.Sensitive = append(append([]SensitiveRule(nil), parent.Sensitive...), child.Sensitive...)
+
+		for profileName, profile := range parent.Profiles {
+			if profile != nil {
+				merged.Profiles[profileName] = copyProfile(profileName, profile)
+			}
+		}
+	}
+
+	for profileName, profile := range child.Profiles {
+		if profile == nil {
+			continue
+		}
+
+		inherited, ok := merged.Profiles[profileName]
+		if !ok {
+			merged.Profiles[profileName] = copyProfile(profileName, profile)
+			continue
+		}
+
+		if profile.Region != "" {
+			inherited.Region = profile.Region
+		}
+		if profile.AccountId != "" || profile.Role != "" {
+			inherited.AccountId = profile.AccountId
+			inherited.Role = profile.Role
+		}
+		if profile.Output != "" {
+			inherited.Output = profile.Output
+		}
+		for key, value := range profile.Settings {
+			if inherited.Settings == nil {
+				inherited.Settings = make(map[string]string)
+			}
+			inherited.Settings[key] = value
+		}
+	}
+
+	return &merged
+}
+
+// copyProfile copies profile, including its settings, so changes to the copy do not leak into the config it came from
+func copyProfile(profileName string, profile *Profile) *Profile {
+	copied := *profile
+	copied.Name = profileName
+	if profile.Settings != nil {
+		copied.Settings = make(map[string]string, len(profile.Settings))
+		for key, value := range profile.Settings {
+			copied.Settings[key] = value
+		}
+	}
+
+	return &copied
+}
+
+// InheritingConfigs returns the names of the configs that inherit from configName, directly or not, sorted.
+func (f *ConfigFile) InheritingConfigs(configName string) []string {
+	var inheriting []string
+	for name, config := range f.Configs {
+		visited := map[string]bool{name: true}
+		for config != nil && config.Extends != "" && !visited[config.Extends] {
+			if config.Extends == configName {
+				inheriting = append(inheriting, name)
+				break
+			}
+			visited[config.Extends] = true
+			config = f.Configs[config.Extends]
+		}
+	}
+	sort.Strings(inheriting)
+
+	return inheriting
+}
@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var errLockTimeout = errors.New("timed out waiting for the lock")
+
+const lockPollInterval = 250 * time.Millisecond
+
+// heldFileLocks holds the names of the files this process has locked
+var heldFileLocks sync.Map
+
+// withFileLock runs action while holding an exclusive advisory lock on a sibling ".lock" file of name, so concurrent
+// awsx processes don't lose each other's read-modify-write updates. Locks are not reentrant, action must not lock name again.
+func withFileLock(name string, action func() error) error {
+	return withFileLockTimeout(name, 0, nil, action)
+}
+
+// withFileLockTimeout is withFileLock giving up with errLockTimeout after timeout. A zero timeout waits forever.
+// waiting is called once if the lock is held by another process.
+func withFileLockTimeout(name string, timeout time.Duration, waiting func(), action func() error) error {
+	err := os.MkdirAll(filepath.Dir(name), 0700)
+	if err != nil {
+		return err
+	}
+
+	lock, err := os.OpenFile(name+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer func(lock *os.File) {
+		_ = lock.Close()
+	}(lock)
+
+	if timeout == 0 && waiting == nil {
+		err = lockFile(lock)
+	} else {
+		err = pollLockFile(lock, timeout, waiting)
+	}
+	if err != nil {
+		return err
+	}
+	defer func(lock *os.File) {
+		_ = unlockFile(lock)
+	}(lock)
+
+	heldFileLocks.Store(filepath.Clean(name), true)
+	defer heldFileLocks.Delete(filepath.Clean(name))
+
+	return action()
+}
+
+// holdsFileLock tells whether this process holds the lock of name
+func holdsFileLock(name string) bool {
+	_, held := heldFileLocks.Load(filepath.Clean(name))
+	return held
+}
+
+func pollLockFile(lock *os.File, timeout time.Duration, waiting func()) error {
+	deadline := time.Now().Add(timeout)
+	for first := true; ; first = false {
+		locked, err := tryLockFile(lock)
+		if err != nil || locked {
+			return err
+		}
+
+		if first && waiting != nil {
+			waiting()
+		}
+
+		if timeout > 0 && time.Now().After(deadline) {
+			return errLockTimeout
+		}
+
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// writeFileAtomic writes content to a temporary file next to name and renames it into place, so readers never see a
+// partially written file. Symlinks are followed, so files linked from a dotfiles repository stay links.
+func writeFileAtomic(name string, content []byte, perm os.FileMode) error {
+	name, err := resolveSymlinks(name)
+	if err != nil {
+		return err
+	}
+
+	directory := filepath.Dir(name)
+	err = os.MkdirAll(directory, 0700)
+	if err != nil {
+		return err
+	}
+
+	temporary, err := os.CreateTemp(directory, "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	temporaryName := temporary.Name()
+	defer func() {
+		_ = os.Remove(temporaryName)
+	}()
+
+	if _, err = temporary.Write(content); err != nil {
+		_ = temporary.Close()
+		return err
+	}
+
+	if err = temporary.Sync(); err != nil {
+		_ = temporary.Close()
+		return err
+	}
+
+	if err = temporary.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Chmod(temporaryName, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryName, name)
+}
+
+// resolveSymlinks returns the file name points to. A link to a file that does not exist yet resolves to that file.
+func resolveSymlinks(name string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(name)
+	if err == nil {
+		return resolved, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	target, err := os.Readlink(name)
+	if err != nil {
+		return name, nil
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(name), target)
+	}
+
+	return target, nil
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"fmt"
+	"github.com/vahid-haghighat/awsx/version"
+	"gopkg.in/yaml.v3"
+	"os"
+	"time"
+)
+
+const schemaVersionKey = "schema_version"
+
+// files written before schema versions were introduced are version 1
+const legacySchemaVersion = 1
+
+// migration upgrades a decoded document by exactly one schema version
+type migration func(document map[string]any) error
+
+type fileSchema struct {
+	name       string
+	migrations []migration
+}
+
+// current is the schema version written by this build. migrations[i] upgrades version i+1 to i+2.
+func (s fileSchema) current() int {
+	return legacySchemaVersion + len(s.migrations)
+}
+
+var configFileSchema = fileSchema{
+	name: "config",
+}
+
+var clientInformationFileSchema = fileSchema{
+	name: "token cache",
+}
+
+var lastUsageFileSchema = fileSchema{
+	name: "usage history",
+	migrations: []migration{
+		addUsageCounts,
+	},
+}
+
+// addUsageCounts counts every entry of a version 1 history as used once. Version 1 recorded neither use counts nor
+// timestamps.
+func addUsageCounts(document map[string]any) error {
+	configs, ok := document["last_usage_information"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	for configName, entries := range configs {
+		list, ok := entries.([]any)
+		if !ok {
+			return fmt.Errorf("the history of config \"%s\" is not a list", configName)
+		}
+
+		for _, entry := range list {
+			if fields, ok := entry.(map[string]any); ok {
+				fields["use_count"] = 1
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateContent upgrades content to the current schema. It fails for content written by a newer awsx.
+// The returned flag tells whether anything changed.
+func migrateContent(content []byte, schema fileSchema) ([]byte, bool, error) {
+	document := make(map[string]any)
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, false, err
+	}
+
+	schemaVersion := legacySchemaVersion
+	if value, ok := document[schemaVersionKey]; ok {
+		number, ok := value.(int)
+		if !ok || number < legacySchemaVersion {
+			return nil, false, fmt.Errorf("the %s file has an invalid %s: %v", schema.name, schemaVersionKey, value)
+		}
+		schemaVersion = number
+	}
+
+	if schemaVersion > schema.current() {
+		return nil, false, fmt.Errorf("the %s file was written by a newer version of awsx (schema version %d, awsx v%s supports up to %d). please upgrade awsx", schema.name, schemaVersion, version.Version, schema.current())
+	}
+
+	if schemaVersion == schema.current() {
+		return content, false, nil
+	}
+
+	for ; schemaVersion < schema.current(); schemaVersion++ {
+		if err := schema.migrations[schemaVersion-legacySchemaVersion](document); err != nil {
+			return nil, false, fmt.Errorf("failed to migrate the %s file from schema version %d: %w", schema.name, schemaVersion, err)
+		}
+	}
+
+	document[schemaVersionKey] = schemaVersion
+	document["version"] = version.Version
+
+	migrated, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return migrated, true, nil
+}
+
+// readMigratedFile reads a state file and upgrades it in place to the current schema, keeping a backup of the
+// original next to it. The rewrite happens under the lock of the file, so it cannot overwrite a newer version written
+// by another process.
+func readMigratedFile(fileName string, schema fileSchema) ([]byte, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated, changed, err := migrateContent(content, schema)
+	if err != nil || !changed {
+		return migrated, err
+	}
+
+	// writers read the file while they hold its lock already
+	if holdsFileLock(fileName) {
+		return migrated, writeMigratedFile(fileName, schema, content, migrated)
+	}
+
+	err = withFileLock(fileName, func() error {
+		// another process may have migrated or replaced the file in the meantime
+		content, err = os.ReadFile(fileName)
+		if err != nil {
+			return err
+		}
+
+		migrated, changed, err = migrateContent(content, schema)
+		if err != nil || !changed {
+			return err
+		}
+
+		return writeMigratedFile(fileName, schema, content, migrated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return migrated, nil
+}
+
+func writeMigratedFile(fileName string, schema fileSchema, original []byte, migrated []byte) error {
+	backupFileName := fmt.Sprintf("%s.%s.bak", fileName, time.Now().UTC().Format("20060102T150405Z"))
+	if err := writeFileAtomic(backupFileName, original, 0600); err != nil {
+		return fmt.Errorf("failed to back up the %s file before migrating it: %w", schema.name, err)
+	}
+
+	return writeFileAtomic(fileName, migrated, 0600)
+}
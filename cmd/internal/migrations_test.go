@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrateContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		schema      fileSchema
+		content     string
+		wantChanged bool
+		wantErr     string
+		// check inspects the migrated document
+		check func(t *testing.T, document map[string]any)
+	}{
+		{
+			name:        "legacy history gets use counts",
+			schema:      lastUsageFileSchema,
+			content:     "last_usage_information:\n  work:\n    - account_id: \"123456789012\"\n      role: Admin\n",
+			wantChanged: true,
+			check: func(t *testing.T, document map[string]any) {
+				if document[schemaVersionKey] != lastUsageFileSchema.current() {
+					t.Errorf("schema version = %v, want %d", document[schemaVersionKey], lastUsageFileSchema.current())
+				}
+				entry := document["last_usage_information"].(map[string]any)["work"].([]any)[0].(map[string]any)
+				if entry["use_count"] != 1 || entry["role"] != "Admin" {
+					t.Errorf("entry = %v, want a use count of 1 and the role kept", entry)
+				}
+			},
+		},
+		{
+			name:    "current schema is left alone",
+			schema:  lastUsageFileSchema,
+			content: "schema_version: 2\nlast_usage_information: {}\n",
+		},
+		{
+			name:    "schema without migrations",
+			schema:  configFileSchema,
+			content: "configs: {}\n",
+		},
+		{
+			name:    "newer schema",
+			schema:  lastUsageFileSchema,
+			content: "schema_version: 3\n",
+			wantErr: "written by a newer version of awsx",
+		},
+		{
+			name:    "invalid schema version",
+			schema:  lastUsageFileSchema,
+			content: "schema_version: two\n",
+			wantErr: "invalid schema_version",
+		},
+		{
+			name:    "malformed history",
+			schema:  lastUsageFileSchema,
+			content: "last_usage_information:\n  work: nope\n",
+			wantErr: "is not a list",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrated, changed, err := migrateContent([]byte(test.content), test.schema)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("migrateContent() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if changed != test.wantChanged {
+				t.Errorf("migrateContent() changed = %v, want %v", changed, test.wantChanged)
+			}
+			if !changed && string(migrated) != test.content {
+				t.Errorf("migrateContent() rewrote content it did not change: %q", migrated)
+			}
+
+			if test.check != nil {
+				document := make(map[string]any)
+				if err = yaml.Unmarshal(migrated, &document); err != nil {
+					t.Fatal(err)
+				}
+				test.check(t, document)
+			}
+		})
+	}
+}
+
+func TestReadMigratedFile(t *testing.T) {
+	directory := isolatePaths(t)
+	fileName := filepath.Join(directory, "last-usage")
+	legacy := "last_usage_information:\n  work:\n    - account_id: \"123456789012\"\n      role: Admin\n"
+	writeTestFile(t, fileName, legacy)
+
+	migrated, err := readMigratedFile(fileName, lastUsageFileSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stored) != string(migrated) {
+		t.Error("the migrated content was not written back")
+	}
+
+	backups, err := filepath.Glob(fileName + ".*.bak")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("backups = %v, want one", backups)
+	}
+	if backup, _ := os.ReadFile(backups[0]); string(backup) != legacy {
+		t.Errorf("backup = %q, want the original content", backup)
+	}
+
+	// a migrated file is neither rewritten nor backed up again
+	if _, err = readMigratedFile(fileName, lastUsageFileSchema); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ = filepath.Glob(fileName + ".*.bak"); len(backups) != 1 {
+		t.Errorf("backups = %v, want one", backups)
+	}
+}
@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const awsxHomeEnvironmentVariable = "AWSX_HOME"
+
+var pathOverrides = struct {
+	credentialsFile string
+	awsConfigFile   string
+	awsxHome        string
+}{}
+
+// OverridePaths sets paths passed on the command line. They take precedence over the environment.
+func OverridePaths(credentialsFile string, awsConfigFile string, awsxHome string) {
+	pathOverrides.credentialsFile = expandHome(credentialsFile)
+	pathOverrides.awsConfigFile = expandHome(awsConfigFile)
+	pathOverrides.awsxHome = expandHome(awsxHome)
+}
+
+// PathEnvironment returns the environment variables that make another awsx process resolve the same paths as this one.
+func PathEnvironment() []string {
+	environment := []string{
+		"AWS_SHARED_CREDENTIALS_FILE=" + awsCredentialsFilePath(),
+		"AWS_CONFIG_FILE=" + awsConfigFilePath(),
+	}
+
+	if awsxHome := awsxHomePath(); awsxHome != "" {
+		return append(environment, awsxHomeEnvironmentVariable+"="+awsxHome)
+	}
+
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME"} {
+		if value := os.Getenv(name); value != "" {
+			environment = append(environment, name+"="+value)
+		}
+	}
+
+	return environment
+}
+
+func homePath() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+func expandHome(target string) string {
+	if target == "~" {
+		return homePath()
+	}
+
+	if strings.HasPrefix(target, "~/") || strings.HasPrefix(target, "~"+string(filepath.Separator)) {
+		return path.Join(homePath(), target[2:])
+	}
+
+	return target
+}
+
+func awsCredentialsFilePath() string {
+	if pathOverrides.credentialsFile != "" {
+		return pathOverrides.credentialsFile
+	}
+
+	if credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); credentialsFile != "" {
+		return expandHome(credentialsFile)
+	}
+
+	return path.Join(homePath(), ".aws", "credentials")
+}
+
+func awsConfigFilePath() string {
+	if pathOverrides.awsConfigFile != "" {
+		return pathOverrides.awsConfigFile
+	}
+
+	if configFile := os.Getenv("AWS_CONFIG_FILE"); configFile != "" {
+		return expandHome(configFile)
+	}
+
+	return path.Join(homePath(), ".aws", "config")
+}
+
+func awsxHomePath() string {
+	if pathOverrides.awsxHome != "" {
+		return pathOverrides.awsxHome
+	}
+
+	return expandHome(os.Getenv(awsxHomeEnvironmentVariable))
+}
+
+// internalPath holds the awsx config. AWSX_HOME wins over XDG_CONFIG_HOME, the default is ~/.config/awsx.
+func internalPath() string {
+	if awsxHome := awsxHomePath(); awsxHome != "" {
+		return awsxHome
+	}
+
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return orLegacyPath(path.Join(expandHome(configHome), "awsx"), legacyInternalPath())
+	}
+
+	return legacyInternalPath()
+}
+
+// cachePath holds tokens, usage history and backups. AWSX_HOME wins over XDG_CACHE_HOME, the default is the cache
+// directory inside internalPath.
+func cachePath() string {
+	if awsxHome := awsxHomePath(); awsxHome != "" {
+		return path.Join(awsxHome, "cache")
+	}
+
+	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
+		return orLegacyPath(path.Join(expandHome(cacheHome), "awsx"), path.Join(legacyInternalPath(), "cache"))
+	}
+
+	return path.Join(internalPath(), "cache")
+}
+
+// legacyInternalPath is where awsx kept its config and cache before it followed the XDG variables
+func legacyInternalPath() string {
+	return path.Join(homePath(), ".config", "awsx")
+}
+
+// orLegacyPath keeps using the directory of an existing installation until the XDG directory exists, so setting the
+// XDG variables does not lose the config, tokens and history.
+func orLegacyPath(xdgPath string, legacyPath string) string {
+	if _, err := os.Stat(xdgPath); err == nil {
+		return xdgPath
+	}
+
+	if info, err := os.Stat(legacyPath); err == nil && info.IsDir() {
+		return legacyPath
+	}
+
+	return xdgPath
+}
+
+func configFileName() string {
+	return path.Join(internalPath(), "config")
+}
+
+func clientInformationFileName() string {
+	return path.Join(cachePath(), "access-token")
+}
+
+func encryptedClientInformationFileName() string {
+	return path.Join(cachePath(), "access-token.enc")
+}
+
+// auditLogFileName lives next to the config instead of the cache, clearing caches must not drop audit records
+func auditLogFileName() string {
+	return path.Join(internalPath(), "audit.jsonl")
+}
+
+// trustedProjectsFileName lives next to the config, the trust decisions are settings rather than a cache
+func trustedProjectsFileName() string {
+	return path.Join(internalPath(), "trusted-projects")
+}
+
+func lastUsageFileName() string {
+	return path.Join(cachePath(), "last-usage")
+}
+
+func ecsServerFileName() string {
+	return path.Join(cachePath(), "ecs-server")
+}
+
+func backupPath() string {
+	return path.Join(cachePath(), "backups")
+}
@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/vahid-haghighat/awsx/version"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+)
+
+const projectConfigFileName = ".awsx.yaml"
+
+// ProjectConfig pins the config, profile and optionally the account, role and region a repository uses. It is read
+// from the nearest .awsx.yaml above the working directory.
+type ProjectConfig struct {
+	Config    string `yaml:"config"`
+	Profile   string `yaml:"profile,omitempty"`
+	AccountId string `yaml:"account_id,omitempty"`
+	Role      string `yaml:"role,omitempty"`
+	Region    string `yaml:"region,omitempty"`
+	Path      string `yaml:"-"`
+	Hash      string `yaml:"-"`
+	Allowed   bool   `yaml:"-"`
+}
+
+// TrustedProjectsFile records the .awsx.yaml files the user allowed, by path and the hash of their content. A file
+// that changes after it was allowed has to be allowed again.
+type TrustedProjectsFile struct {
+	Version  string            `yaml:"version"`
+	Projects map[string]string `yaml:"projects"`
+}
+
+// FindProjectConfig walks up from directory and reads the first .awsx.yaml it finds. It returns nil when there is none.
+func FindProjectConfig(directory string) (*ProjectConfig, error) {
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		fileName := filepath.Join(directory, projectConfigFileName)
+		content, err := os.ReadFile(fileName)
+		if err == nil {
+			return parseProjectConfig(fileName, content)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(directory)
+		if parent == directory {
+			return nil, nil
+		}
+		directory = parent
+	}
+}
+
+func parseProjectConfig(fileName string, content []byte) (*ProjectConfig, error) {
+	projectConfig := &ProjectConfig{}
+	if err := yaml.Unmarshal(content, projectConfig); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fileName, err)
+	}
+	projectConfig.Path = fileName
+
+	hash := sha256.Sum256(content)
+	projectConfig.Hash = hex.EncodeToString(hash[:])
+	trusted, err := readTrustedProjectsFile()
+	if err != nil {
+		return nil, err
+	}
+	projectConfig.Allowed = trusted.Projects[fileName] == projectConfig.Hash
+
+	if projectConfig.Config == "" {
+		projectConfig.Config = "default"
+	}
+
+	var problems []error
+	if projectConfig.Profile != "" {
+		if err := ValidateProfileName(projectConfig.Profile); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if projectConfig.AccountId != "" && !accountIdPattern.MatchString(projectConfig.AccountId) {
+		problems = append(problems, fmt.Errorf("account id \"%s\" must be 12 digits", projectConfig.AccountId))
+	}
+
+	if (projectConfig.AccountId == "") != (projectConfig.Role == "") {
+		problems = append(problems, errors.New("account_id and role must be set together"))
+	}
+
+	if projectConfig.Region != "" {
+		if err := ValidateRegion(projectConfig.Region); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s is invalid:\n%w", fileName, errors.Join(problems...))
+	}
+
+	return projectConfig, nil
+}
+
+// NotAllowedError is returned for project files the user did not allow yet. A cloned repository must not switch the
+// account, role or profile awsx uses without the user reviewing it first.
+func (p *ProjectConfig) NotAllowedError() error {
+	return fmt.Errorf("%s is not allowed. review it and run \"awsx project allow\" to use it", p.Path)
+}
+
+// AllowProject trusts the current content of the project file, or revokes the trust when allowed is false.
+func AllowProject(projectConfig *ProjectConfig, allowed bool) error {
+	fileName := trustedProjectsFileName()
+	return withFileLock(fileName, func() error {
+		trusted, err := readTrustedProjectsFile()
+		if err != nil {
+			return err
+		}
+
+		if allowed {
+			trusted.Projects[projectConfig.Path] = projectConfig.Hash
+		} else {
+			delete(trusted.Projects, projectConfig.Path)
+		}
+
+		trusted.Version = version.Version
+		content, err := yaml.Marshal(trusted)
+		if err != nil {
+			return err
+		}
+
+		return writeFileAtomic(fileName, content, 0600)
+	})
+}
+
+func readTrustedProjectsFile() (*TrustedProjectsFile, error) {
+	trusted := &TrustedProjectsFile{}
+	content, err := os.ReadFile(trustedProjectsFileName())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err = yaml.Unmarshal(content, trusted); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", trustedProjectsFileName(), err)
+	}
+	if trusted.Projects == nil {
+		trusted.Projects = make(map[string]string)
+	}
+
+	return trusted, nil
+}
+
+// ResolveProfile returns the profile of config the project uses, without its overrides. It returns nil without an
+// error when the project does not name a profile and the config has more than one.
+func (p *ProjectConfig) ResolveProfile(config *Config) (*Profile, error) {
+	var profile *Profile
+	if p.Profile != "" {
+		var ok bool
+		if profile, ok = config.Profiles[p.Profile]; !ok {
+			return nil, fmt.Errorf("profile \"%s\" from %s does not exist in config \"%s\"", p.Profile, p.Path, p.Config)
+		}
+	} else if len(config.Profiles) == 1 {
+		for _, onlyProfile := range config.Profiles {
+			profile = onlyProfile
+		}
+	} else {
+		return nil, nil
+	}
+
+	return profile, nil
+}
+
+// Apply returns a copy of profile with the account, role and region of the project.
+func (p *ProjectConfig) Apply(profile *Profile) *Profile {
+	resolved := *profile
+	if p.AccountId != "" {
+		resolved.AccountId = p.AccountId
+		resolved.Role = p.Role
+	}
+	if p.Region != "" {
+		resolved.Region = p.Region
+	}
+
+	return &resolved
+}
+
+// ProjectProfileName returns the name of the profile the project in directory uses, or an empty string when there is
+// no project or it does not name a single profile.
+func ProjectProfileName(directory string) (string, error) {
+	projectConfig, err := FindProjectConfig(directory)
+	if err != nil || projectConfig == nil {
+		return "", err
+	}
+	if !projectConfig.Allowed {
+		return "", projectConfig.NotAllowedError()
+	}
+
+	if projectConfig.Profile != "" {
+		return projectConfig.Profile, nil
+	}
+
+	configs, err := ReadInternalConfig()
+	if err != nil {
+		return "", err
+	}
+
+	config, ok := configs[projectConfig.Config]
+	if !ok {
+		return "", fmt.Errorf("config \"%s\" from %s does not exist", projectConfig.Config, projectConfig.Path)
+	}
+
+	profile, err := projectConfig.ResolveProfile(config)
+	if err != nil || profile == nil {
+		return "", err
+	}
+
+	return profile.Name, nil
+}
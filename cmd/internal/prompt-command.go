@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// commandPrompter delegates to an external program such as "rofi -dmenu", "dmenu" or "fzf --height 10". The program
+// is called without extra arguments and gets AWSX_SELECTOR_MODE (select, prompt or secret), AWSX_SELECTOR_LABEL and,
+// for prompts, AWSX_SELECTOR_DEFAULT in its environment. For select the items are written to its stdin one per line
+// and it prints the chosen line. For prompt and secret its stdin is empty and it prints the value, a secret should not
+// be shown while it is typed, for example with "rofi -dmenu -password". Printing nothing cancels a select and keeps the
+// default of a prompt.
+type commandPrompter struct {
+	command string
+}
+
+func (p *commandPrompter) Select(label string, toSelect []string, _ func(input string, index int) bool) (int, string, error) {
+	if len(toSelect) == 0 {
+		return 0, "", errors.New("nothing to select")
+	}
+
+	lines := make([]string, len(toSelect))
+	for index, item := range toSelect {
+		lines[index] = strings.ReplaceAll(item, "\n", " ")
+	}
+
+	output, err := p.run("select", label, "", strings.Join(lines, "\n")+"\n")
+	if err != nil {
+		return 0, "", err
+	}
+	if output == "" {
+		return 0, "", errors.New("nothing was selected")
+	}
+
+	for index, line := range lines {
+		if line == output {
+			return index, toSelect[index], nil
+		}
+	}
+
+	return 0, "", fmt.Errorf("selector command printed \"%s\", which is not one of the items", output)
+}
+
+func (p *commandPrompter) Prompt(label string, dfault string) (string, error) {
+	output, err := p.run("prompt", label, dfault, "")
+	if err != nil {
+		return "", err
+	}
+	if output == "" {
+		return dfault, nil
+	}
+
+	return output, nil
+}
+
+func (p *commandPrompter) Secret(label string) (string, error) {
+	return p.run("secret", label, "", "")
+}
+
+// run returns the first line the command printed, without surrounding whitespace
+func (p *commandPrompter) run(mode string, label string, dfault string, input string) (string, error) {
+	fields := strings.Fields(p.command)
+	if len(fields) == 0 {
+		return "", errors.New("no selector command configured")
+	}
+
+	command := exec.Command(fields[0], fields[1:]...)
+	command.Env = append(os.Environ(), "AWSX_SELECTOR_MODE="+mode, "AWSX_SELECTOR_LABEL="+label, "AWSX_SELECTOR_DEFAULT="+dfault)
+	command.Stdin = strings.NewReader(input)
+	command.Stderr = os.Stderr
+
+	output, err := command.Output()
+	if err != nil {
+		return "", fmt.Errorf("selector command \"%s\" failed: %w", p.command, err)
+	}
+
+	line, _, _ := strings.Cut(string(bytes.TrimLeft(output, "\r\n")), "\n")
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// fzfPrompter selects with fzf, which draws on the terminal directly and works under tmux and in most editor shells.
+// fzf does its own fuzzy matching, so the searchers of the callers are not used. Values are entered line by line.
+type fzfPrompter struct{}
+
+func (p *fzfPrompter) Select(label string, toSelect []string, _ func(input string, index int) bool) (int, string, error) {
+	if len(toSelect) == 0 {
+		return 0, "", errors.New("nothing to select")
+	}
+
+	// every line carries its index, so equal items can be told apart
+	var input bytes.Buffer
+	for index, item := range toSelect {
+		input.WriteString(strconv.Itoa(index) + "\t" + strings.ReplaceAll(item, "\n", " ") + "\n")
+	}
+
+	command := exec.Command("fzf", "--header", label, "--delimiter", "\t", "--with-nth", "2..", "--layout", "reverse", "--height", "40%", "--no-multi")
+	command.Stdin = &input
+	command.Stderr = os.Stderr
+
+	output, err := command.Output()
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) && (exitError.ExitCode() == 1 || exitError.ExitCode() == 130) {
+		// 1 means nothing matched the query, 130 that the user cancelled
+		return 0, "", errors.New("nothing was selected")
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("fzf failed: %w", err)
+	}
+
+	indexField, _, _ := strings.Cut(strings.TrimRight(string(output), "\n"), "\t")
+	index, err := strconv.Atoi(indexField)
+	if err != nil || index < 0 || index >= len(toSelect) {
+		return 0, "", fmt.Errorf("unexpected fzf output \"%s\"", strings.TrimSpace(string(output)))
+	}
+
+	return index, toSelect[index], nil
+}
+
+func (p *fzfPrompter) Prompt(label string, dfault string) (string, error) {
+	return readLine(label, dfault)
+}
+
+func (p *fzfPrompter) Secret(label string) (string, error) {
+	return readSecret(label)
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// stdinReader is shared by every prompt, a reader of its own would swallow the answers buffered for the next prompt
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// plainPrompter prints numbered lists and reads answers line by line, for dumb terminals, editor shells and pipes.
+// Everything but the answers goes to stderr, so it works with stdout redirected.
+type plainPrompter struct{}
+
+func (p *plainPrompter) Select(label string, toSelect []string, searcher func(input string, index int) bool) (int, string, error) {
+	if len(toSelect) == 0 {
+		return 0, "", errors.New("nothing to select")
+	}
+
+	candidates := make([]int, len(toSelect))
+	for index := range toSelect {
+		candidates[index] = index
+	}
+
+	for {
+		_, _ = fmt.Fprintln(os.Stderr, label)
+		for _, index := range candidates {
+			_, _ = fmt.Fprintln(os.Stderr, "  "+numberedItem(toSelect[index], index))
+		}
+
+		answer, err := readLine("Enter #<number> to choose, or text to filter the list", "")
+		if err != nil {
+			return 0, "", err
+		}
+
+		// only #<number> chooses directly, plain digits such as part of an account id filter the list
+		if number, found := strings.CutPrefix(answer, "#"); found {
+			if index, err := strconv.Atoi(number); err == nil && slices.Contains(candidates, index) {
+				return index, toSelect[index], nil
+			}
+		}
+
+		// anything else narrows the list, an empty answer shows all items again
+		var matching []int
+		for index := range toSelect {
+			if answer == "" || matchesSearch(searcher, toSelect, answer, index) {
+				matching = append(matching, index)
+			}
+		}
+
+		switch len(matching) {
+		case 0:
+			_, _ = fmt.Fprintf(os.Stderr, "Nothing matches \"%s\"\n", answer)
+		case 1:
+			return matching[0], toSelect[matching[0]], nil
+		default:
+			candidates = matching
+		}
+	}
+}
+
+func (p *plainPrompter) Prompt(label string, dfault string) (string, error) {
+	return readLine(label, dfault)
+}
+
+func (p *plainPrompter) Secret(label string) (string, error) {
+	return readSecret(label)
+}
+
+// numberedItem labels the item with its index, unless the caller already did, as the account and role lists do
+func numberedItem(item string, index int) string {
+	number := "#" + strconv.Itoa(index)
+	if strings.HasPrefix(item, number+" ") {
+		return item
+	}
+
+	return number + " " + item
+}
+
+// matchesSearch uses the searcher of the caller, or a case insensitive substring match when there is none
+func matchesSearch(searcher func(input string, index int) bool, toSelect []string, input string, index int) bool {
+	if searcher != nil {
+		return searcher(input, index)
+	}
+
+	return strings.Contains(strings.ToLower(toSelect[index]), strings.ToLower(input))
+}
+
+// readLine prints label and the default answer to stderr and reads one line from stdin. An empty line means the
+// default. The end of the input cancels the prompt.
+func readLine(label string, dfault string) (string, error) {
+	if dfault != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "%s [%s]: ", label, dfault)
+	} else {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: ", label)
+	}
+
+	line, err := stdinReader.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		_, _ = fmt.Fprintln(os.Stderr)
+		return "", errors.New("no answer, the input ended")
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return dfault, nil
+	}
+
+	return line, nil
+}
+
+// readSecret reads a line like readLine without showing it. Echo can only be turned off on a terminal, a secret piped
+// to awsx is read as it is.
+func readSecret(label string) (string, error) {
+	if isTerminal(os.Stdin) && os.Getenv("TERM") != "dumb" {
+		// character devices such as /dev/null are no terminals, echo cannot be turned off for them
+		if restore, err := disableEcho(os.Stdin); err == nil {
+			defer restore()
+			// the newline typed by the user is not shown either
+			defer func() {
+				_, _ = fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+
+	return readLine(label, "")
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// replaceStdin makes the prompts read input instead of the real stdin.
+func replaceStdin(t *testing.T, input string) {
+	t.Helper()
+
+	previous := stdinReader
+	stdinReader = bufio.NewReader(strings.NewReader(input))
+	t.Cleanup(func() {
+		stdinReader = previous
+	})
+}
+
+func TestPlainPrompterSelect(t *testing.T) {
+	accounts := []string{"#0 dev 111122223333 - Admin", "#1 prod 123456789012 - Admin", "#2 test 999912345678 - ReadOnly"}
+
+	tests := []struct {
+		name      string
+		items     []string
+		searcher  func(input string, index int) bool
+		input     string
+		wantIndex int
+		wantErr   bool
+	}{
+		{name: "number matches the label", items: accounts, searcher: fuzzySearchWithPrefixAnchor(accounts, "#"), input: "#1\n", wantIndex: 1},
+		{name: "plain digits filter", items: accounts, searcher: fuzzySearchWithPrefixAnchor(accounts, "#"), input: "9999\n", wantIndex: 2},
+		{name: "digits in several items narrow the list", items: accounts, input: "12\n#1\n", wantIndex: 1},
+		{name: "number outside the filtered list filters instead", items: accounts, input: "prod\n#0\n", wantIndex: 1},
+		{name: "items without labels are numbered from 0", items: []string{"Yes", "No"}, input: "#0\n", wantIndex: 0},
+		{name: "text filters case insensitively", items: []string{"Yes", "No"}, input: "no\n", wantIndex: 1},
+		{name: "end of input cancels", items: []string{"Yes", "No"}, input: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			replaceStdin(t, test.input)
+
+			index, value, err := (&plainPrompter{}).Select("Select", test.items, test.searcher)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Select() = %d, want an error", index)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if index != test.wantIndex || value != test.items[test.wantIndex] {
+				t.Errorf("Select() = %d %q, want %d %q", index, value, test.wantIndex, test.items[test.wantIndex])
+			}
+		})
+	}
+}
+
+func TestNumberedItem(t *testing.T) {
+	tests := []struct {
+		item  string
+		index int
+		want  string
+	}{
+		{item: "Yes", index: 0, want: "#0 Yes"},
+		{item: "#3 prod 123456789012", index: 3, want: "#3 prod 123456789012"},
+		{item: "#3 prod", index: 4, want: "#4 #3 prod"},
+	}
+
+	for _, test := range tests {
+		if got := numberedItem(test.item, test.index); got != test.want {
+			t.Errorf("numberedItem(%q, %d) = %q, want %q", test.item, test.index, got, test.want)
+		}
+	}
+}
@@ -1,16 +1,133 @@
 package internal
 
 import (
+	"fmt"
 	"github.com/lithammer/fuzzysearch/fuzzy"
 	"github.com/manifoldco/promptui"
+	"os"
+	"os/exec"
 	"strings"
 )
 
+const (
+	SelectorAuto          = "auto"
+	SelectorPromptui      = "promptui"
+	SelectorFzf           = "fzf"
+	SelectorPlain         = "plain"
+	SelectorCommandPrefix = "command:"
+)
+
+const selectorEnvironmentVariable = "AWSX_SELECTOR"
+
+// promptsDisabled is set for commands that must not wait for input, such as the scheduled refreshes
+var promptsDisabled bool
+
+// DisablePrompts makes prompts that cannot be skipped, such as the one for the token cache passphrase, fail instead of
+// waiting for input.
+func DisablePrompts() {
+	promptsDisabled = true
+}
+
+// Prompt asks the user to pick one of several items, to enter a value or to enter a secret that is not shown. Every
+// backend returns an error when the user cancels, so callers never act on a selection that was not made.
 type Prompt interface {
 	Select(label string, toSelect []string, searcher func(input string, index int) bool) (index int, value string, err error)
 	Prompt(label string, dfault string) (string, error)
+	Secret(label string) (string, error)
 }
 
+func ValidateSelector(name string) error {
+	switch {
+	case name == "", name == SelectorAuto, name == SelectorPromptui, name == SelectorFzf, name == SelectorPlain:
+		return nil
+	case strings.HasPrefix(name, SelectorCommandPrefix) && strings.TrimSpace(strings.TrimPrefix(name, SelectorCommandPrefix)) != "":
+		return nil
+	default:
+		return fmt.Errorf("unknown selector \"%s\". valid values are %s, %s, %s, %s and %s<command>", name, SelectorAuto, SelectorPromptui, SelectorFzf, SelectorPlain, SelectorCommandPrefix)
+	}
+}
+
+// NewSelector returns the backend called name. auto picks the plain backend for dumb terminals and pipes, fzf when it
+// is on the PATH, and promptui otherwise.
+func NewSelector(name string) (Prompt, error) {
+	if err := ValidateSelector(name); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case name == SelectorPromptui:
+		return Prompter{}, nil
+	case name == SelectorFzf:
+		if _, err := exec.LookPath("fzf"); err != nil {
+			return nil, fmt.Errorf("selector \"%s\" needs fzf on the PATH", name)
+		}
+		return &fzfPrompter{}, nil
+	case name == SelectorPlain:
+		return &plainPrompter{}, nil
+	case strings.HasPrefix(name, SelectorCommandPrefix):
+		return &commandPrompter{command: strings.TrimSpace(strings.TrimPrefix(name, SelectorCommandPrefix))}, nil
+	}
+
+	if !interactiveTerminal() {
+		return &plainPrompter{}, nil
+	}
+	if _, err := exec.LookPath("fzf"); err == nil {
+		return &fzfPrompter{}, nil
+	}
+
+	return Prompter{}, nil
+}
+
+// SelectorName returns the selector chosen with AWSX_SELECTOR, falling back to the installation wide setting.
+func SelectorName() string {
+	if name := os.Getenv(selectorEnvironmentVariable); name != "" {
+		return name
+	}
+
+	configFile, err := ReadInternalConfigFile()
+	if err != nil || configFile == nil || configFile.Selector == "" {
+		return SelectorAuto
+	}
+
+	return configFile.Selector
+}
+
+// DefaultSelector returns the selector the user chose, see SelectorName.
+func DefaultSelector() (Prompt, error) {
+	return NewSelector(SelectorName())
+}
+
+// SetSelector selects the selector for the installation.
+func SetSelector(name string) error {
+	if err := ValidateSelector(name); err != nil {
+		return err
+	}
+
+	if name == SelectorAuto {
+		name = ""
+	}
+
+	return updateInternalConfigFile(func(configFile *ConfigFile) error {
+		configFile.Selector = name
+		return nil
+	})
+}
+
+// interactiveTerminal tells whether stdin and stdout are terminals that can draw a full screen list
+func interactiveTerminal() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Prompter is the promptui backend, an interactive list with fuzzy search.
 type Prompter struct{}
 
 func (receiver Prompter) Select(label string, toSelect []string, searcher func(input string, index int) bool) (int, string, error) {
@@ -42,6 +159,15 @@ func (receiver Prompter) Prompt(label string, dfault string) (string, error) {
 	return val, nil
 }
 
+func (receiver Prompter) Secret(label string) (string, error) {
+	prompt := promptui.Prompt{
+		Label: label,
+		Mask:  '*',
+	}
+
+	return prompt.Run()
+}
+
 func fuzzySearchWithPrefixAnchor(itemsToSelect []string, linePrefix string) func(input string, index int) bool {
 	return func(input string, index int) bool {
 		role := itemsToSelect[index]
@@ -2,92 +2,118 @@ package internal
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/service/sso"
 	ssoTypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
 	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
 	"log"
 	"strconv"
-	"strings"
 	"time"
 )
 
-func RefreshCredentials(configName string, profile *Profile, oidcClient *ssooidc.Client, ssoClient *sso.Client, config *Config, selector Prompt) error {
+// RefreshCredentials writes new credentials for a recently used account and role to the profile. breakGlassReason
+// justifies the use of sensitive roles without prompting.
+func RefreshCredentials(configName string, profile *Profile, oidcClient *ssooidc.Client, ssoClient *sso.Client, config *Config, selector Prompt, nonInteractive bool, breakGlassReason string) error {
 	clientInformation, err := GetClientInformationForConfig(configName)
 	if err != nil {
-		clientInformation, _ = ProcessClientInformation(configName, config.GetStartUrl(), oidcClient)
+		return err
+	}
+
+	// logging in needs the user to confirm the device code in a browser
+	if accessTokenExpired, clientSecretExpired := clientInformation.IsExpired(); accessTokenExpired || clientSecretExpired {
+		if nonInteractive {
+			return fmt.Errorf("access token for config \"%s\" has expired. run \"awsx refresh %s\" without --non-interactive to log in again", configName, configName)
+		}
+
+		clientInformation, err = ProcessClientInformation(configName, config, oidcClient)
+		if err != nil {
+			return err
+		}
 	}
 
 	log.Printf("Using Start URL %s", clientInformation.StartUrl)
 
+	breakGlass := BreakGlass{Reason: breakGlassReason, NonInteractive: nonInteractive, Selector: selector}
+	if profile.Pinned() {
+		return refreshPinnedCredentials(configName, config, profile, ssoClient, clientInformation, breakGlass)
+	}
+
 	var accountId *string
 	var roleName *string
 
-	luis, err := GetUsageInformationForConfig(configName)
+	luis, _ := GetUsageInformationForConfig(configName)
+
+	// the most recently used roles are offered, the most frecent of them first
+	recent := luis
+	if len(recent) > config.LastUsedAccountsCount {
+		recent = recent[:config.LastUsedAccountsCount]
+	}
+	ranked := SortByFrecency(recent)
 
 	var toSelect []string
 	linePrefix := "#"
 
-	for i, info := range luis {
-		if i >= config.LastUsedAccountsCount {
-			break
-		}
+	for i, info := range ranked {
 		toSelect = append(toSelect, linePrefix+strconv.Itoa(i)+" "+info.AccountName+" "+info.AccountId+" - "+info.Role)
 	}
 
 	var lui LastUsageInformation
-	if len(toSelect) == 0 {
+	if len(toSelect) == 0 && nonInteractive {
+		return fmt.Errorf("nothing to refresh yet for config \"%s\"", configName)
+	} else if len(toSelect) == 0 {
 		log.Println("Nothing to refresh yet.")
-		accountInfo := RetrieveAccountInfo(clientInformation, ssoClient, Prompter{})
-		roleInfo := RetrieveRoleInfo(accountInfo, clientInformation, ssoClient, Prompter{})
-		roleName = roleInfo.RoleName
-		accountId = accountInfo.AccountId
-		err := SaveUsageInformation(configName, accountInfo, roleInfo)
+		accountInfo, err := RetrieveAccountInfo(configName, clientInformation, ssoClient, selector)
+		if err != nil {
+			return err
+		}
+		roleInfo, err := RetrieveRoleInfo(configName, accountInfo, clientInformation, ssoClient, selector)
 		if err != nil {
 			return err
 		}
+		lui = LastUsageInformation{
+			AccountId:   *accountInfo.AccountId,
+			AccountName: *accountInfo.AccountName,
+			Role:        *roleInfo.RoleName,
+		}
 	} else if len(toSelect) == 1 {
 		log.Printf("There is only one role available for refresh")
+		lui = ranked[0]
+	} else if nonInteractive {
+		log.Printf("Refreshing the most recently used role")
 		lui = luis[0]
 	} else {
 		label := "Select an account/role combination - Hint: fuzzy search supported. To choose one account directly just enter #{Int}"
-		indexChoice, _, _ := selector.Select(label, toSelect, fuzzySearchWithPrefixAnchor(toSelect, linePrefix))
-		lui = luis[indexChoice]
+		indexChoice, _, err := selector.Select(label, toSelect, fuzzySearchWithPrefixAnchor(toSelect, linePrefix))
+		if err != nil {
+			return err
+		}
+		lui = ranked[indexChoice]
 	}
 
-	log.Printf("Attempting to refresh credentials for account [%s] with role [%s]", lui.AccountName, lui.Role)
+	justification, err := breakGlass.Confirm(config, lui.AccountId, lui.AccountName, lui.Role)
 	if err != nil {
-		if strings.Contains(err.Error(), "no such file") {
-			log.Println("Nothing to refresh yet.")
-			accountInfo := RetrieveAccountInfo(clientInformation, ssoClient, Prompter{})
-			roleInfo := RetrieveRoleInfo(accountInfo, clientInformation, ssoClient, Prompter{})
-			roleName = roleInfo.RoleName
-			accountId = accountInfo.AccountId
-			err := SaveUsageInformation(configName, accountInfo, roleInfo)
-			if err != nil {
-				return err
-			}
-		}
-	} else {
-		accountId = &lui.AccountId
-		roleName = &lui.Role
+		return err
 	}
 
+	log.Printf("Attempting to refresh credentials for account [%s] with role [%s]", lui.AccountName, lui.Role)
+	accountId = &lui.AccountId
+	roleName = &lui.Role
+	lui.Profile = profile.Name
+
 	rci := &sso.GetRoleCredentialsInput{AccountId: accountId, RoleName: roleName, AccessToken: &clientInformation.AccessToken}
 	roleCredentials, err := ssoClient.GetRoleCredentials(context.Background(), rci)
 	if err != nil {
 		return err
 	}
+	AuditCredentials("refresh", configName, config, profile.Name, lui.AccountId, lui.AccountName, lui.Role, justification, roleCredentials.RoleCredentials)
 
-	err = WriteAwsConfigFile(profile.Name, config, roleCredentials.RoleCredentials)
+	err = WriteAwsConfigFile(profile, roleCredentials.RoleCredentials)
 	if err != nil {
 		return err
 	}
 
-	err = SetUsageInformationForConfig(configName, &lui)
-
-	if accountId == nil || roleName == nil {
-		return errors.New("no account or role found")
+	if err = SetUsageInformationForConfig(configName, &lui); err != nil {
+		return err
 	}
 
 	log.Printf("Retrieved credentials for account %s successfully", *accountId)
@@ -96,10 +122,40 @@ func RefreshCredentials(configName string, profile *Profile, oidcClient *ssooidc
 	return nil
 }
 
-func SaveUsageInformation(configName string, accountInfo ssoTypes.AccountInfo, roleInfo ssoTypes.RoleInfo) error {
+// refreshPinnedCredentials refreshes a profile that always uses the same account and role, the usage history is neither
+// consulted nor updated.
+func refreshPinnedCredentials(configName string, config *Config, profile *Profile, ssoClient *sso.Client, clientInformation *ClientInformation, breakGlass BreakGlass) error {
+	accountName := LookupAccountName(configName, config, profile.AccountId, clientInformation, ssoClient)
+	justification, err := breakGlass.Confirm(config, profile.AccountId, accountName, profile.Role)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attempting to refresh credentials for account [%s] with role [%s]", profile.AccountId, profile.Role)
+	rci := &sso.GetRoleCredentialsInput{AccountId: &profile.AccountId, RoleName: &profile.Role, AccessToken: &clientInformation.AccessToken}
+	roleCredentials, err := ssoClient.GetRoleCredentials(context.Background(), rci)
+	if err != nil {
+		return err
+	}
+	AuditCredentials("refresh", configName, config, profile.Name, profile.AccountId, accountName, profile.Role, justification, roleCredentials.RoleCredentials)
+
+	err = WriteAwsConfigFile(profile, roleCredentials.RoleCredentials)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Retrieved credentials for account %s successfully", profile.AccountId)
+	log.Printf("Assumed role: %s", profile.Role)
+	log.Printf("Credentials expire at: %s\n", time.Unix(roleCredentials.RoleCredentials.Expiration/1000, 0))
+	return nil
+}
+
+// SaveUsageInformation records that the role of the account was written to the profile named profileName.
+func SaveUsageInformation(configName string, profileName string, accountInfo ssoTypes.AccountInfo, roleInfo ssoTypes.RoleInfo) error {
 	return SetUsageInformationForConfig(configName, &LastUsageInformation{
 		AccountId:   *accountInfo.AccountId,
 		AccountName: *accountInfo.AccountName,
 		Role:        *roleInfo.RoleName,
+		Profile:     profileName,
 	})
 }
@@ -0,0 +1,342 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const scheduleUnitPrefix = "awsx-refresh-"
+const scheduleCronMarker = "# awsx-schedule:"
+
+type ScheduleBackend string
+
+const (
+	ScheduleBackendSystemd ScheduleBackend = "systemd"
+	ScheduleBackendCron    ScheduleBackend = "cron"
+)
+
+type Schedule struct {
+	Name    string
+	Backend ScheduleBackend
+	Command string
+}
+
+var unsafeFileNameCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+func ScheduleName(configName string, profileName string) string {
+	return scheduleUnitPrefix + unsafeFileNameCharacters.ReplaceAllString(configName+"-"+profileName, "_")
+}
+
+func InstallSchedule(configName string, profileName string, interval time.Duration) (*Schedule, error) {
+	if interval < time.Minute {
+		return nil, errors.New("interval must be at least one minute")
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{executable, "refresh", configName, "--profile", profileName, "--non-interactive"}
+	name := ScheduleName(configName, profileName)
+
+	if systemdAvailable() {
+		return installSystemdSchedule(name, configName, profileName, args, PathEnvironment(), interval)
+	}
+
+	if _, err = exec.LookPath("crontab"); err == nil {
+		return installCronSchedule(name, args, PathEnvironment(), interval)
+	}
+
+	return nil, fmt.Errorf("neither systemd nor cron is available on %s", runtime.GOOS)
+}
+
+func ListSchedules() ([]Schedule, error) {
+	var schedules []Schedule
+
+	timers, _ := filepath.Glob(path.Join(systemdUserUnitPath(), scheduleUnitPrefix+"*.timer"))
+	for _, timer := range timers {
+		name := strings.TrimSuffix(path.Base(timer), ".timer")
+		command := ""
+		if content, err := os.ReadFile(path.Join(systemdUserUnitPath(), name+".service")); err == nil {
+			for _, line := range strings.Split(string(content), "\n") {
+				if strings.HasPrefix(line, "ExecStart=") {
+					command = strings.TrimPrefix(line, "ExecStart=")
+				}
+			}
+		}
+		schedules = append(schedules, Schedule{Name: name, Backend: ScheduleBackendSystemd, Command: command})
+	}
+
+	lines, err := readCrontab()
+	if err != nil {
+		return schedules, nil
+	}
+
+	for _, line := range lines {
+		index := strings.Index(line, scheduleCronMarker)
+		if index < 0 {
+			continue
+		}
+		schedules = append(schedules, Schedule{
+			Name:    cronUnescape(strings.TrimSpace(line[index+len(scheduleCronMarker):])),
+			Backend: ScheduleBackendCron,
+			Command: cronUnescape(strings.TrimSpace(line[:index])),
+		})
+	}
+
+	sort.SliceStable(schedules, func(i, j int) bool {
+		return schedules[i].Name < schedules[j].Name
+	})
+
+	return schedules, nil
+}
+
+func RemoveSchedule(name string) error {
+	removed := false
+
+	timerFile := path.Join(systemdUserUnitPath(), name+".timer")
+	if _, err := os.Stat(timerFile); err == nil {
+		if systemdAvailable() {
+			_ = exec.Command("systemctl", "--user", "disable", "--now", name+".timer").Run()
+		}
+
+		for _, unitFile := range []string{timerFile, path.Join(systemdUserUnitPath(), name+".service")} {
+			if err = os.Remove(unitFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+
+		if systemdAvailable() {
+			_ = exec.Command("systemctl", "--user", "daemon-reload").Run()
+		}
+		removed = true
+	}
+
+	lines, err := readCrontab()
+	if err == nil {
+		var kept []string
+		for _, line := range lines {
+			if strings.HasSuffix(strings.TrimSpace(line), cronEscape(scheduleCronMarker+name)) {
+				removed = true
+				continue
+			}
+			kept = append(kept, line)
+		}
+
+		if len(kept) != len(lines) {
+			if err = writeCrontab(kept); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !removed {
+		return fmt.Errorf("schedule \"%s\" does not exist", name)
+	}
+
+	return nil
+}
+
+func systemdAvailable() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return false
+	}
+
+	return exec.Command("systemctl", "--user", "show-environment").Run() == nil
+}
+
+func systemdUserUnitPath() string {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return path.Join(configHome, "systemd", "user")
+	}
+
+	return path.Join(homePath(), ".config", "systemd", "user")
+}
+
+func installSystemdSchedule(name string, configName string, profileName string, args []string, environment []string, interval time.Duration) (*Schedule, error) {
+	err := os.MkdirAll(systemdUserUnitPath(), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	var quoted []string
+	for _, arg := range args {
+		quoted = append(quoted, systemdQuote(arg))
+	}
+	command := strings.Join(quoted, " ")
+
+	environmentLines := ""
+	for _, variable := range environment {
+		environmentLines += "Environment=" + systemdQuote(variable) + "\n"
+	}
+
+	service := fmt.Sprintf(`[Unit]
+Description=Refresh awsx credentials for config %s profile %s
+
+[Service]
+Type=oneshot
+%sExecStart=%s
+`, configName, profileName, environmentLines, command)
+
+	timer := fmt.Sprintf(`[Unit]
+Description=Periodically refresh awsx credentials for config %s profile %s
+
+[Timer]
+OnActiveSec=1min
+OnUnitActiveSec=%ds
+
+[Install]
+WantedBy=timers.target
+`, configName, profileName, int(interval.Seconds()))
+
+	err = os.WriteFile(path.Join(systemdUserUnitPath(), name+".service"), []byte(service), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(path.Join(systemdUserUnitPath(), name+".timer"), []byte(timer), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if output, err := exec.Command("systemctl", "--user", "daemon-reload").CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to reload systemd: %s", strings.TrimSpace(string(output)))
+	}
+
+	if output, err := exec.Command("systemctl", "--user", "enable", "--now", name+".timer").CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to enable %s.timer: %s", name, strings.TrimSpace(string(output)))
+	}
+
+	return &Schedule{Name: name, Backend: ScheduleBackendSystemd, Command: command}, nil
+}
+
+func installCronSchedule(name string, args []string, environment []string, interval time.Duration) (*Schedule, error) {
+	spec, err := cronSpec(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var quoted []string
+	for _, variable := range environment {
+		name, value, _ := strings.Cut(variable, "=")
+		quoted = append(quoted, name+"="+shellQuote(value))
+	}
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	command := strings.Join(quoted, " ") + " >/dev/null 2>&1"
+
+	lines, _ := readCrontab()
+	var kept []string
+	for _, line := range lines {
+		if !strings.HasSuffix(strings.TrimSpace(line), cronEscape(scheduleCronMarker+name)) {
+			kept = append(kept, line)
+		}
+	}
+	kept = append(kept, fmt.Sprintf("%s %s %s", spec, cronEscape(command), cronEscape(scheduleCronMarker+name)))
+
+	if err := writeCrontab(kept); err != nil {
+		return nil, err
+	}
+
+	return &Schedule{Name: name, Backend: ScheduleBackendCron, Command: spec + " " + command}, nil
+}
+
+// cronSpec returns the cron schedule for interval. cron can only repeat steps that divide an hour or a day evenly,
+// anything else would run at uneven times.
+func cronSpec(interval time.Duration) (string, error) {
+	minutes := int(interval / time.Minute)
+	switch {
+	case interval%time.Minute != 0:
+	case minutes < 60 && 60%minutes == 0:
+		return fmt.Sprintf("*/%d * * * *", minutes), nil
+	case minutes == 60:
+		return "0 * * * *", nil
+	case minutes%60 == 0 && minutes < 24*60 && (24*60)%minutes == 0:
+		return fmt.Sprintf("0 */%d * * *", minutes/60), nil
+	case minutes == 24*60:
+		return "0 0 * * *", nil
+	}
+
+	return "", fmt.Errorf("cron cannot run every %s. use an interval that divides an hour or a day evenly, such as 15m, 30m, 2h or 6h", interval)
+}
+
+func readCrontab() ([]string, error) {
+	if _, err := exec.LookPath("crontab"); err != nil {
+		return nil, err
+	}
+
+	output, err := exec.Command("crontab", "-l").Output()
+	if err != nil {
+		// crontab -l exits with an error when the user has no crontab yet
+		return nil, nil
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+func writeCrontab(lines []string) error {
+	content := strings.Join(lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
+
+	command := exec.Command("crontab", "-")
+	command.Stdin = bytes.NewBufferString(content)
+	if output, err := command.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to update crontab: %s", strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func systemdQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$%;") {
+		return arg
+	}
+
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	arg = strings.ReplaceAll(arg, `"`, `\"`)
+	arg = strings.ReplaceAll(arg, `$`, `$$`)
+	arg = strings.ReplaceAll(arg, `%`, `%%`)
+	return `"` + arg + `"`
+}
+
+// cronEscape escapes the % signs of a crontab command, cron turns them into newlines even inside quotes
+func cronEscape(command string) string {
+	return strings.ReplaceAll(command, "%", `\%`)
+}
+
+func cronUnescape(command string) string {
+	return strings.ReplaceAll(command, `\%`, "%")
+}
+
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$;&|<>()*?`#%") {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"log"
+	"sync"
+	"time"
+)
+
+// credentials are renewed this long before they expire
+const credentialsRefreshWindow = 5 * time.Minute
+
+type RoleCredentialsProvider struct {
+	ConfigName    string
+	Config        *Config
+	AccountId     string
+	RoleName      string
+	Justification string
+	oidcClient    *ssooidc.Client
+	ssoClient     *sso.Client
+	mutex         sync.Mutex
+	credentials   *ssoTypes.RoleCredentials
+}
+
+func NewRoleCredentialsProvider(configName string, config *Config, accountId string, roleName string, oidcClient *ssooidc.Client, ssoClient *sso.Client) *RoleCredentialsProvider {
+	return &RoleCredentialsProvider{
+		ConfigName: configName,
+		Config:     config,
+		AccountId:  accountId,
+		RoleName:   roleName,
+		oidcClient: oidcClient,
+		ssoClient:  ssoClient,
+	}
+}
+
+// Retrieve returns cached role credentials, fetching new ones through the SSO client when they are about to expire.
+func (p *RoleCredentialsProvider) Retrieve() (*ssoTypes.RoleCredentials, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.credentials != nil && time.UnixMilli(p.credentials.Expiration).After(time.Now().Add(credentialsRefreshWindow)) {
+		return p.credentials, nil
+	}
+
+	clientInformation, err := ProcessClientInformation(p.ConfigName, p.Config, p.oidcClient)
+	if err != nil {
+		return nil, err
+	}
+
+	rci := &sso.GetRoleCredentialsInput{AccountId: &p.AccountId, RoleName: &p.RoleName, AccessToken: &clientInformation.AccessToken}
+	roleCredentials, err := p.ssoClient.GetRoleCredentials(context.Background(), rci)
+	if err != nil {
+		return nil, err
+	}
+
+	p.credentials = roleCredentials.RoleCredentials
+	AuditCredentials("serve", p.ConfigName, p.Config, "", p.AccountId, "", p.RoleName, p.Justification, p.credentials)
+	log.Printf("Retrieved credentials for account %s with role %s. They expire at: %s\n", p.AccountId, p.RoleName, time.UnixMilli(p.credentials.Expiration))
+	return p.credentials, nil
+}
+
+func newRandomToken() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
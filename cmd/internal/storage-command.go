@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// commandTokenStorage delegates to an external helper, for example a wrapper around pass. The helper is called as
+// "<command> get <key>", "<command> store <key>" and "<command> erase <key>". get prints the stored value and prints
+// nothing when the key is unknown, store reads the value from stdin.
+type commandTokenStorage struct {
+	command string
+}
+
+func (s *commandTokenStorage) Load(configName string) (*ClientInformation, error) {
+	output, err := s.run("get", configName, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil, ErrClientInformationNotFound
+	}
+
+	clientInformation := &ClientInformation{}
+	err = yaml.Unmarshal(output, clientInformation)
+	if err != nil {
+		return nil, err
+	}
+
+	return clientInformation, nil
+}
+
+func (s *commandTokenStorage) Save(configName string, clientInformation *ClientInformation) error {
+	content, err := yaml.Marshal(clientInformation)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.run("store", configName, content)
+	return err
+}
+
+func (s *commandTokenStorage) Delete(configName string) error {
+	_, err := s.run("erase", configName, nil)
+	return err
+}
+
+func (s *commandTokenStorage) run(operation string, configName string, input []byte) ([]byte, error) {
+	fields := strings.Fields(s.command)
+	if len(fields) == 0 {
+		return nil, errors.New("no token storage command configured")
+	}
+
+	command := exec.Command(fields[0], append(fields[1:], operation, "awsx/"+configName)...)
+	command.Stdin = bytes.NewReader(input)
+	command.Stderr = os.Stderr
+
+	output, err := command.Output()
+	if err != nil {
+		return output, fmt.Errorf("token storage command failed to %s the client information of \"%s\": %w", operation, configName, err)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,213 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/vahid-haghighat/awsx/version"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+const encryptedCacheMagic = "AWSX-ENC-1\n"
+const encryptedCacheSaltSize = 16
+const cachePassphraseEnvironmentVariable = "AWSX_CACHE_PASSPHRASE"
+
+// the passphrase is asked for at most once per invocation
+var cachePassphrase string
+
+// encryptedTokenStorage keeps all client information in a single file encrypted with AES-GCM under a scrypt derived key.
+type encryptedTokenStorage struct{}
+
+func (s *encryptedTokenStorage) Load(configName string) (*ClientInformation, error) {
+	clientInformationFile, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	clientInformation, exists := clientInformationFile.ClientInformation[configName]
+	if !exists {
+		return nil, ErrClientInformationNotFound
+	}
+
+	return clientInformation, nil
+}
+
+func (s *encryptedTokenStorage) Save(configName string, clientInformation *ClientInformation) error {
+	return withFileLock(encryptedClientInformationFileName(), func() error {
+		clientInformationFile, err := s.read()
+		if err != nil {
+			return err
+		}
+
+		clientInformationFile.ClientInformation[configName] = clientInformation
+		return s.write(clientInformationFile)
+	})
+}
+
+func (s *encryptedTokenStorage) Delete(configName string) error {
+	return withFileLock(encryptedClientInformationFileName(), func() error {
+		clientInformationFile, err := s.read()
+		if err != nil {
+			return err
+		}
+
+		if _, exists := clientInformationFile.ClientInformation[configName]; !exists {
+			return nil
+		}
+
+		delete(clientInformationFile.ClientInformation, configName)
+		return s.write(clientInformationFile)
+	})
+}
+
+func (s *encryptedTokenStorage) read() (*ClientInformationFile, error) {
+	emptyClientInformationFile := &ClientInformationFile{
+		Version:           version.Version,
+		SchemaVersion:     clientInformationFileSchema.current(),
+		ClientInformation: make(map[string]*ClientInformation),
+	}
+
+	file, err := os.ReadFile(encryptedClientInformationFileName())
+	if errors.Is(err, os.ErrNotExist) {
+		return emptyClientInformationFile, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(file, []byte(encryptedCacheMagic)) || len(file) < len(encryptedCacheMagic)+encryptedCacheSaltSize {
+		return nil, errors.New("the encrypted token cache is corrupted")
+	}
+	file = file[len(encryptedCacheMagic):]
+	salt, sealed := file[:encryptedCacheSaltSize], file[encryptedCacheSaltSize:]
+
+	aead, err := newCacheCipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("the encrypted token cache is corrupted")
+	}
+
+	content, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(encryptedCacheMagic))
+	if err != nil {
+		cachePassphrase = ""
+		return nil, errors.New("failed to decrypt the token cache. is the passphrase correct?")
+	}
+
+	content, _, err = migrateContent(content, clientInformationFileSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	clientInformationFile := &ClientInformationFile{}
+	err = yaml.Unmarshal(content, clientInformationFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientInformationFile.ClientInformation == nil {
+		clientInformationFile.ClientInformation = make(map[string]*ClientInformation)
+	}
+
+	return clientInformationFile, nil
+}
+
+func (s *encryptedTokenStorage) write(clientInformationFile *ClientInformationFile) error {
+	clientInformationFile.Version = version.Version
+	clientInformationFile.SchemaVersion = clientInformationFileSchema.current()
+	content, err := yaml.Marshal(clientInformationFile)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, encryptedCacheSaltSize)
+	if _, err = rand.Read(salt); err != nil {
+		return err
+	}
+
+	aead, err := newCacheCipher(salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+
+	output := append([]byte(encryptedCacheMagic), salt...)
+	output = append(output, nonce...)
+	output = aead.Seal(output, nonce, content, []byte(encryptedCacheMagic))
+
+	return writeFileAtomic(encryptedClientInformationFileName(), output, 0600)
+}
+
+func newCacheCipher(salt []byte) (cipher.AEAD, error) {
+	passphrase, err := getCachePassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func getCachePassphrase() (string, error) {
+	if cachePassphrase != "" {
+		return cachePassphrase, nil
+	}
+
+	if passphrase := os.Getenv(cachePassphraseEnvironmentVariable); passphrase != "" {
+		cachePassphrase = passphrase
+		return cachePassphrase, nil
+	}
+
+	if promptsDisabled {
+		return "", fmt.Errorf("the token cache is encrypted and %s is not set. set it to use the cache non-interactively", cachePassphraseEnvironmentVariable)
+	}
+
+	selector, err := DefaultSelector()
+	if err != nil {
+		return "", err
+	}
+
+	passphrase, err := selector.Secret("Token cache passphrase")
+	if err != nil {
+		return "", err
+	}
+
+	if passphrase == "" {
+		return "", errors.New("the token cache passphrase cannot be empty")
+	}
+
+	// a typo in the passphrase the cache is created with would lock the user out of it
+	if _, err = os.Stat(encryptedClientInformationFileName()); errors.Is(err, os.ErrNotExist) {
+		repeated, err := selector.Secret("Repeat the token cache passphrase")
+		if err != nil {
+			return "", err
+		}
+
+		if repeated != passphrase {
+			return "", errors.New("the token cache passphrases do not match")
+		}
+	}
+
+	cachePassphrase = passphrase
+	return cachePassphrase, nil
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedTokenStorage(t *testing.T) {
+	tests := []struct {
+		name           string
+		savePassphrase string
+		loadPassphrase string
+		// corrupt replaces the cache file after it was written
+		corrupt func(content []byte) []byte
+		wantErr string
+	}{
+		{
+			name:           "round trip",
+			savePassphrase: "correct horse",
+			loadPassphrase: "correct horse",
+		},
+		{
+			name:           "wrong passphrase",
+			savePassphrase: "correct horse",
+			loadPassphrase: "battery staple",
+			wantErr:        "is the passphrase correct?",
+		},
+		{
+			name:           "tampered ciphertext",
+			savePassphrase: "correct horse",
+			loadPassphrase: "correct horse",
+			corrupt: func(content []byte) []byte {
+				content[len(content)-1] ^= 0xff
+				return content
+			},
+			wantErr: "is the passphrase correct?",
+		},
+		{
+			name:           "missing header",
+			savePassphrase: "correct horse",
+			loadPassphrase: "correct horse",
+			corrupt: func(content []byte) []byte {
+				return content[len(encryptedCacheMagic):]
+			},
+			wantErr: "corrupted",
+		},
+		{
+			name:           "truncated",
+			savePassphrase: "correct horse",
+			loadPassphrase: "correct horse",
+			corrupt: func(content []byte) []byte {
+				return content[:len(encryptedCacheMagic)+encryptedCacheSaltSize+2]
+			},
+			wantErr: "corrupted",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isolatePaths(t)
+			t.Cleanup(func() {
+				cachePassphrase = ""
+			})
+
+			storage := &encryptedTokenStorage{}
+			saved := &ClientInformation{AccessToken: "access-token", ClientId: "client-id", StartUrl: "https://d-1234567890.awsapps.com/start"}
+
+			cachePassphrase = ""
+			t.Setenv(cachePassphraseEnvironmentVariable, test.savePassphrase)
+			if err := storage.Save("work", saved); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(encryptedClientInformationFileName())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(content), saved.AccessToken) {
+				t.Fatal("the access token is stored in plain text")
+			}
+			if test.corrupt != nil {
+				writeTestFile(t, encryptedClientInformationFileName(), string(test.corrupt(content)))
+			}
+
+			cachePassphrase = ""
+			t.Setenv(cachePassphraseEnvironmentVariable, test.loadPassphrase)
+			loaded, err := storage.Load("work")
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *loaded != *saved {
+				t.Errorf("Load() = %+v, want %+v", loaded, saved)
+			}
+			if _, err = storage.Load("other"); !errors.Is(err, ErrClientInformationNotFound) {
+				t.Errorf("Load() of an unknown config error = %v, want %v", err, ErrClientInformationNotFound)
+			}
+		})
+	}
+}
+
+func TestEncryptedTokenStorageNonInteractive(t *testing.T) {
+	isolatePaths(t)
+	t.Cleanup(func() {
+		cachePassphrase = ""
+		promptsDisabled = false
+	})
+
+	cachePassphrase = ""
+	promptsDisabled = true
+	err := (&encryptedTokenStorage{}).Save("work", &ClientInformation{})
+	if err == nil || !strings.Contains(err.Error(), cachePassphraseEnvironmentVariable) {
+		t.Errorf("Save() error = %v, want an error naming %s", err, cachePassphraseEnvironmentVariable)
+	}
+}
+
+func TestEncryptedTokenStorageConfirmsNewPassphrase(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "matching", input: "secret\nsecret\n"},
+		{name: "typo", input: "secret\nsecrte\n", wantErr: "do not match"},
+		{name: "empty", input: "\n", wantErr: "cannot be empty"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isolatePaths(t)
+			t.Setenv(selectorEnvironmentVariable, SelectorPlain)
+			replaceStdin(t, test.input)
+			t.Cleanup(func() {
+				cachePassphrase = ""
+			})
+
+			cachePassphrase = ""
+			err := (&encryptedTokenStorage{}).Save("work", &ClientInformation{AccessToken: "access-token"})
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Save() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the cache exists now, so the passphrase is asked for once
+			cachePassphrase = ""
+			replaceStdin(t, "secret\n")
+			if _, err = (&encryptedTokenStorage{}).Load("work"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
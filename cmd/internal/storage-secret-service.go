@@ -0,0 +1,230 @@
+package internal
+
+import (
+	"errors"
+	"github.com/godbus/dbus/v5"
+	"gopkg.in/yaml.v3"
+	"time"
+)
+
+const secretServiceName = "org.freedesktop.secrets"
+const secretServicePath dbus.ObjectPath = "/org/freedesktop/secrets"
+const secretServiceDefaultCollection dbus.ObjectPath = "/org/freedesktop/secrets/aliases/default"
+const secretServiceInterface = "org.freedesktop.Secret.Service"
+const secretCollectionInterface = "org.freedesktop.Secret.Collection"
+const secretItemInterface = "org.freedesktop.Secret.Item"
+const secretPromptInterface = "org.freedesktop.Secret.Prompt"
+const secretSessionInterface = "org.freedesktop.Secret.Session"
+
+const secretServicePromptTimeout = 2 * time.Minute
+
+type secretServiceSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// secretServiceTokenStorage keeps the client information of each config as an item of the default collection of the
+// freedesktop Secret Service, for example GNOME Keyring or KWallet.
+type secretServiceTokenStorage struct{}
+
+func (s *secretServiceTokenStorage) Load(configName string) (*ClientInformation, error) {
+	connection, session, err := openSecretServiceSession()
+	if err != nil {
+		return nil, err
+	}
+	defer closeSecretServiceSession(connection, session)
+
+	items, err := searchSecretServiceItems(connection, configName)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrClientInformationNotFound
+	}
+
+	secrets := make(map[dbus.ObjectPath]secretServiceSecret)
+	err = connection.Object(secretServiceName, secretServicePath).
+		Call(secretServiceInterface+".GetSecrets", 0, items[:1], session).Store(&secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, ok := secrets[items[0]]
+	if !ok {
+		return nil, ErrClientInformationNotFound
+	}
+
+	clientInformation := &ClientInformation{}
+	err = yaml.Unmarshal(secret.Value, clientInformation)
+	if err != nil {
+		return nil, err
+	}
+
+	return clientInformation, nil
+}
+
+func (s *secretServiceTokenStorage) Save(configName string, clientInformation *ClientInformation) error {
+	content, err := yaml.Marshal(clientInformation)
+	if err != nil {
+		return err
+	}
+
+	connection, session, err := openSecretServiceSession()
+	if err != nil {
+		return err
+	}
+	defer closeSecretServiceSession(connection, session)
+
+	if err = unlockSecretServiceObjects(connection, []dbus.ObjectPath{secretServiceDefaultCollection}); err != nil {
+		return err
+	}
+
+	properties := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label":      dbus.MakeVariant("awsx access token for " + configName),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant(secretServiceAttributes(configName)),
+	}
+	secret := secretServiceSecret{
+		Session:     session,
+		Parameters:  []byte{},
+		Value:       content,
+		ContentType: "application/yaml",
+	}
+
+	var item, prompt dbus.ObjectPath
+	err = connection.Object(secretServiceName, secretServiceDefaultCollection).
+		Call(secretCollectionInterface+".CreateItem", 0, properties, secret, true).Store(&item, &prompt)
+	if err != nil {
+		return err
+	}
+
+	return waitForSecretServicePrompt(connection, prompt)
+}
+
+func (s *secretServiceTokenStorage) Delete(configName string) error {
+	connection, session, err := openSecretServiceSession()
+	if err != nil {
+		return err
+	}
+	defer closeSecretServiceSession(connection, session)
+
+	items, err := searchSecretServiceItems(connection, configName)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		err = connection.Object(secretServiceName, item).Call(secretItemInterface+".Delete", 0).Store(&prompt)
+		if err != nil {
+			return err
+		}
+
+		if err = waitForSecretServicePrompt(connection, prompt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func secretServiceAttributes(configName string) map[string]string {
+	return map[string]string{
+		"application": "awsx",
+		"config":      configName,
+	}
+}
+
+func openSecretServiceSession() (*dbus.Conn, dbus.ObjectPath, error) {
+	connection, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, "", errors.New("failed to connect to the session bus. is a Secret Service provider running?")
+	}
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err = connection.Object(secretServiceName, secretServicePath).
+		Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session)
+	if err != nil {
+		_ = connection.Close()
+		return nil, "", err
+	}
+
+	return connection, session, nil
+}
+
+func closeSecretServiceSession(connection *dbus.Conn, session dbus.ObjectPath) {
+	_ = connection.Object(secretServiceName, session).Call(secretSessionInterface+".Close", 0).Err
+	_ = connection.Close()
+}
+
+func searchSecretServiceItems(connection *dbus.Conn, configName string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := connection.Object(secretServiceName, secretServicePath).
+		Call(secretServiceInterface+".SearchItems", 0, secretServiceAttributes(configName)).Store(&unlocked, &locked)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(locked) > 0 {
+		if err = unlockSecretServiceObjects(connection, locked); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(unlocked, locked...), nil
+}
+
+func unlockSecretServiceObjects(connection *dbus.Conn, objects []dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	err := connection.Object(secretServiceName, secretServicePath).
+		Call(secretServiceInterface+".Unlock", 0, objects).Store(&unlocked, &prompt)
+	if err != nil {
+		return err
+	}
+
+	return waitForSecretServicePrompt(connection, prompt)
+}
+
+func waitForSecretServicePrompt(connection *dbus.Conn, prompt dbus.ObjectPath) error {
+	if prompt == "" || prompt == "/" {
+		return nil
+	}
+
+	err := connection.AddMatchSignal(
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface(secretPromptInterface),
+		dbus.WithMatchMember("Completed"),
+	)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan *dbus.Signal, 1)
+	connection.Signal(signals)
+	defer connection.RemoveSignal(signals)
+
+	err = connection.Object(secretServiceName, prompt).Call(secretPromptInterface+".Prompt", 0, "").Err
+	if err != nil {
+		return err
+	}
+
+	timeout := time.After(secretServicePromptTimeout)
+	for {
+		select {
+		case signal := <-signals:
+			if signal.Path != prompt || len(signal.Body) == 0 {
+				continue
+			}
+
+			if dismissed, ok := signal.Body[0].(bool); ok && dismissed {
+				return errors.New("the Secret Service prompt was dismissed")
+			}
+			return nil
+		case <-timeout:
+			return errors.New("timed out waiting for the Secret Service prompt")
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/vahid-haghighat/awsx/version"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+const (
+	TokenStoragePlaintext     = "plaintext"
+	TokenStorageEncrypted     = "encrypted"
+	TokenStorageSecretService = "secret-service"
+	TokenStorageCommandPrefix = "command:"
+)
+
+var ErrClientInformationNotFound = errors.New("no client information found")
+
+// TokenStorage keeps the SSO client registration and access token of each config.
+type TokenStorage interface {
+	Load(configName string) (*ClientInformation, error)
+	Save(configName string, clientInformation *ClientInformation) error
+	Delete(configName string) error
+}
+
+func ValidateTokenStorage(name string) error {
+	switch {
+	case name == "", name == TokenStoragePlaintext, name == TokenStorageEncrypted, name == TokenStorageSecretService:
+		return nil
+	case strings.HasPrefix(name, TokenStorageCommandPrefix) && strings.TrimSpace(strings.TrimPrefix(name, TokenStorageCommandPrefix)) != "":
+		return nil
+	default:
+		return fmt.Errorf("unknown token storage \"%s\". valid values are %s, %s, %s and %s<command>", name, TokenStoragePlaintext, TokenStorageEncrypted, TokenStorageSecretService, TokenStorageCommandPrefix)
+	}
+}
+
+func NewTokenStorage(name string) (TokenStorage, error) {
+	if err := ValidateTokenStorage(name); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case name == TokenStorageEncrypted:
+		return &encryptedTokenStorage{}, nil
+	case name == TokenStorageSecretService:
+		return &secretServiceTokenStorage{}, nil
+	case strings.HasPrefix(name, TokenStorageCommandPrefix):
+		return &commandTokenStorage{command: strings.TrimSpace(strings.TrimPrefix(name, TokenStorageCommandPrefix))}, nil
+	default:
+		return &plaintextTokenStorage{}, nil
+	}
+}
+
+// TokenStorageName returns the storage configured for a config, falling back to the installation wide setting.
+func TokenStorageName(configName string) string {
+	configFile, err := ReadInternalConfigFile()
+	if err != nil || configFile == nil {
+		return TokenStoragePlaintext
+	}
+
+	if config, ok := configFile.Configs[configName]; ok && config.TokenStorage != "" {
+		return config.TokenStorage
+	}
+
+	if configFile.TokenStorage != "" {
+		return configFile.TokenStorage
+	}
+
+	return TokenStoragePlaintext
+}
+
+func tokenStorageForConfig(configName string) (TokenStorage, error) {
+	return NewTokenStorage(TokenStorageName(configName))
+}
+
+// loadClientInformation reads from the configured storage and moves entries still left in the plaintext cache into it.
+func loadClientInformation(configName string) (*ClientInformation, error) {
+	storage, err := tokenStorageForConfig(configName)
+	if err != nil {
+		return nil, err
+	}
+
+	clientInformation, err := storage.Load(configName)
+	if err == nil {
+		return clientInformation, nil
+	}
+
+	if _, plaintext := storage.(*plaintextTokenStorage); plaintext || !errors.Is(err, ErrClientInformationNotFound) {
+		return nil, err
+	}
+
+	plaintextStorage := &plaintextTokenStorage{}
+	clientInformation, err = plaintextStorage.Load(configName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = storage.Save(configName, clientInformation); err != nil {
+		return nil, err
+	}
+
+	return clientInformation, plaintextStorage.Delete(configName)
+}
+
+// MigrateTokenStorage moves the cached client information of a config from one storage to another.
+func MigrateTokenStorage(configName string, from string, to string) error {
+	if from == to {
+		return nil
+	}
+
+	source, err := NewTokenStorage(from)
+	if err != nil {
+		return err
+	}
+
+	destination, err := NewTokenStorage(to)
+	if err != nil {
+		return err
+	}
+
+	clientInformation, err := source.Load(configName)
+	if errors.Is(err, ErrClientInformationNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = destination.Save(configName, clientInformation); err != nil {
+		return err
+	}
+
+	return source.Delete(configName)
+}
+
+// SetTokenStorage selects the storage for one config, or for the installation when configName is empty, and migrates
+// the affected caches into it.
+func SetTokenStorage(configName string, name string) error {
+	if err := ValidateTokenStorage(name); err != nil {
+		return err
+	}
+
+	configs, err := ReadInternalConfig()
+	if err != nil {
+		return errors.New("no configuration found. run \"awsx config\" first")
+	}
+
+	previous := make(map[string]string)
+	for existingName := range configs {
+		previous[existingName] = TokenStorageName(existingName)
+	}
+
+	err = updateInternalConfigFile(func(configFile *ConfigFile) error {
+		if configName == "" {
+			configFile.TokenStorage = name
+			return nil
+		}
+
+		config, ok := configFile.Configs[configName]
+		if !ok {
+			return fmt.Errorf("config \"%s\" does not exist", configName)
+		}
+		config.TokenStorage = name
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for existingName, from := range previous {
+		if err = MigrateTokenStorage(existingName, from, TokenStorageName(existingName)); err != nil {
+			return fmt.Errorf("failed to migrate the cache of config \"%s\": %w", existingName, err)
+		}
+	}
+
+	return nil
+}
+
+type plaintextTokenStorage struct{}
+
+func (s *plaintextTokenStorage) Load(configName string) (*ClientInformation, error) {
+	clientInformationFile, err := ReadClientInformationFile()
+	if err != nil {
+		return nil, err
+	}
+
+	clientInformation, exists := clientInformationFile.ClientInformation[configName]
+	if !exists {
+		return nil, ErrClientInformationNotFound
+	}
+
+	return clientInformation, nil
+}
+
+func (s *plaintextTokenStorage) Save(configName string, clientInformation *ClientInformation) error {
+	return withFileLock(clientInformationFileName(), func() error {
+		clientInformationFile, err := ReadClientInformationFile()
+		if err != nil {
+			return err
+		}
+
+		clientInformationFile.ClientInformation[configName] = clientInformation
+		return writeClientInformationFile(clientInformationFile)
+	})
+}
+
+func (s *plaintextTokenStorage) Delete(configName string) error {
+	return withFileLock(clientInformationFileName(), func() error {
+		clientInformationFile, err := ReadClientInformationFile()
+		if err != nil {
+			return err
+		}
+
+		if _, exists := clientInformationFile.ClientInformation[configName]; !exists {
+			return nil
+		}
+
+		delete(clientInformationFile.ClientInformation, configName)
+		if len(clientInformationFile.ClientInformation) == 0 {
+			err = os.Remove(clientInformationFileName())
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		return writeClientInformationFile(clientInformationFile)
+	})
+}
+
+func writeClientInformationFile(clientInformationFile *ClientInformationFile) error {
+	clientInformationFile.Version = version.Version
+	clientInformationFile.SchemaVersion = clientInformationFileSchema.current()
+	content, err := yaml.Marshal(clientInformationFile)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(clientInformationFileName(), content, 0600)
+}
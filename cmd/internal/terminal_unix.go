@@ -0,0 +1,32 @@
+//go:build !windows
+
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// disableEcho stops the terminal from showing what is typed and returns a function that restores its previous state
+func disableEcho(file *os.File) (func(), error) {
+	state, err := stty(file, "-g")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = stty(file, "-echo"); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_, _ = stty(file, strings.TrimSpace(state))
+	}, nil
+}
+
+func stty(file *os.File, argument string) (string, error) {
+	command := exec.Command("stty", argument)
+	command.Stdin = file
+	output, err := command.Output()
+	return string(output), err
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package internal
+
+import (
+	"golang.org/x/sys/windows"
+	"os"
+)
+
+// disableEcho stops the console from showing what is typed and returns a function that restores its previous mode
+func disableEcho(file *os.File) (func(), error) {
+	handle := windows.Handle(file.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return nil, err
+	}
+
+	if err := windows.SetConsoleMode(handle, mode&^windows.ENABLE_ECHO_INPUT); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_ = windows.SetConsoleMode(handle, mode)
+	}, nil
+}
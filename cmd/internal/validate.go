@@ -0,0 +1,267 @@
+package internal
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+//go:embed partitions.json
+var partitionsJson []byte
+
+type partition struct {
+	Id      string   `json:"id"`
+	Regions []string `json:"regions"`
+}
+
+// regionPartitions maps every known region to the id of its partition
+var regionPartitions = loadRegionPartitions()
+
+// the id of an AWS access portal is the first label of its host name, for example d-1234567890 or a custom alias
+var startUrlIdPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+var accountIdPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// profileOutputs are the output formats the AWS CLI accepts
+var profileOutputs = []string{"json", "yaml", "yaml-stream", "text", "table"}
+
+// a setting is a plain key, or a nested one such as s3.max_concurrent_requests
+var settingKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)?$`)
+
+// reservedSettingKeys are written by awsx itself and cannot be overridden by the settings of a profile
+var reservedSettingKeys = append([]string{"output", "region", awsManagedKey}, awsCredentialKeys...)
+
+func loadRegionPartitions() map[string]string {
+	var document struct {
+		Partitions []partition `json:"partitions"`
+	}
+
+	if err := json.Unmarshal(partitionsJson, &document); err != nil {
+		panic(fmt.Sprintf("invalid embedded partition metadata: %s", err))
+	}
+
+	regions := make(map[string]string)
+	for _, partition := range document.Partitions {
+		for _, region := range partition.Regions {
+			regions[region] = partition.Id
+		}
+	}
+
+	return regions
+}
+
+// ValidateRegion fails for names that are not known AWS regions and suggests the closest known region.
+func ValidateRegion(region string) error {
+	if region == "" {
+		return errors.New("region cannot be empty")
+	}
+
+	if _, ok := regionPartitions[region]; ok {
+		return nil
+	}
+
+	if suggestion := closestRegion(region); suggestion != "" {
+		return fmt.Errorf("unknown region \"%s\". did you mean \"%s\"?", region, suggestion)
+	}
+
+	return fmt.Errorf("unknown region \"%s\"", region)
+}
+
+func closestRegion(region string) string {
+	closest := ""
+	closestDistance := 3
+	for known := range regionPartitions {
+		distance := fuzzy.LevenshteinDistance(region, known)
+		if distance < closestDistance || (distance == closestDistance && closest != "" && known < closest) {
+			closest = known
+			closestDistance = distance
+		}
+	}
+
+	return closest
+}
+
+// ValidateStartUrlId fails for ids that cannot form the host name of a start URL.
+func ValidateStartUrlId(id string) error {
+	if id == "" {
+		return errors.New("start URL id cannot be empty")
+	}
+
+	if !startUrlIdPattern.MatchString(id) {
+		return fmt.Errorf("invalid start URL id \"%s\". expected the <id> of https://<id>.awsapps.com/start", id)
+	}
+
+	return nil
+}
+
+// ValidateProfileName fails for names that cannot be written as a section of the credentials file.
+func ValidateProfileName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("profile name cannot be empty")
+	case strings.TrimSpace(name) != name:
+		return fmt.Errorf("profile name \"%s\" cannot start or end with whitespace", name)
+	case strings.ContainsAny(name, "[]\r\n"):
+		return fmt.Errorf("profile name \"%s\" cannot contain brackets or line breaks", name)
+	case strings.HasPrefix(name, "#") || strings.HasPrefix(name, ";"):
+		return fmt.Errorf("profile name \"%s\" cannot start with a comment character", name)
+	case name == "DEFAULT":
+		// the ini library treats this section as the keys above the first section header
+		return errors.New("profile name \"DEFAULT\" is reserved. use \"default\" instead")
+	}
+
+	return nil
+}
+
+// ValidateProfileOutput fails for output formats the AWS CLI does not know. An empty output means json.
+func ValidateProfileOutput(output string) error {
+	if output == "" || slices.Contains(profileOutputs, output) {
+		return nil
+	}
+
+	return fmt.Errorf("invalid output \"%s\". expected one of %s", output, strings.Join(profileOutputs, ", "))
+}
+
+// ValidateProfileSetting fails for settings that cannot be written to the credentials file or that awsx writes itself.
+func ValidateProfileSetting(key string, value string) error {
+	if !settingKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid setting \"%s\". expected a key such as retry_mode or s3.max_concurrent_requests", key)
+	}
+
+	parent, _, _ := strings.Cut(key, ".")
+	if slices.Contains(reservedSettingKeys, parent) {
+		return fmt.Errorf("setting \"%s\" is written by awsx and cannot be overridden", key)
+	}
+
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("the value of setting \"%s\" cannot contain line breaks", key)
+	}
+
+	return nil
+}
+
+// Validate reports every problem of the config at once.
+func (c *Config) Validate() error {
+	var problems []error
+
+	if err := ValidateStartUrlId(c.Id); err != nil {
+		problems = append(problems, err)
+	}
+
+	ssoPartition := ""
+	if err := ValidateRegion(c.SsoRegion); err != nil {
+		problems = append(problems, fmt.Errorf("SSO %w", err))
+	} else {
+		ssoPartition = regionPartitions[c.SsoRegion]
+	}
+
+	if c.LastUsedAccountsCount < 1 {
+		problems = append(problems, fmt.Errorf("profile count to cache must be at least 1, got %d", c.LastUsedAccountsCount))
+	}
+
+	for _, rule := range c.Sensitive {
+		if err := rule.Validate(); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	profileNames := make([]string, 0, len(c.Profiles))
+	for profileName := range c.Profiles {
+		profileNames = append(profileNames, profileName)
+	}
+	sort.Strings(profileNames)
+
+	for _, profileName := range profileNames {
+		if err := ValidateProfileName(profileName); err != nil {
+			problems = append(problems, err)
+		}
+
+		profile := c.Profiles[profileName]
+		if profile == nil {
+			problems = append(problems, fmt.Errorf("profile \"%s\": region cannot be empty", profileName))
+			continue
+		}
+
+		if profile.AccountId != "" && !accountIdPattern.MatchString(profile.AccountId) {
+			problems = append(problems, fmt.Errorf("profile \"%s\": account id \"%s\" must be 12 digits", profileName, profile.AccountId))
+		}
+
+		if (profile.AccountId == "") != (profile.Role == "") {
+			problems = append(problems, fmt.Errorf("profile \"%s\": account id and role must be set together", profileName))
+		}
+
+		if err := ValidateProfileOutput(profile.Output); err != nil {
+			problems = append(problems, fmt.Errorf("profile \"%s\": %w", profileName, err))
+		}
+
+		settingKeys := make([]string, 0, len(profile.Settings))
+		for key := range profile.Settings {
+			settingKeys = append(settingKeys, key)
+		}
+		sort.Strings(settingKeys)
+
+		for _, key := range settingKeys {
+			if err := ValidateProfileSetting(key, profile.Settings[key]); err != nil {
+				problems = append(problems, fmt.Errorf("profile \"%s\": %w", profileName, err))
+			}
+
+			// a key cannot hold a value and nested values at the same time
+			if parent, _, nested := strings.Cut(key, "."); nested {
+				if _, ok := profile.Settings[parent]; ok {
+					problems = append(problems, fmt.Errorf("profile \"%s\": setting \"%s\" conflicts with setting \"%s\"", profileName, key, parent))
+				}
+			}
+		}
+
+		if err := ValidateRegion(profile.Region); err != nil {
+			problems = append(problems, fmt.Errorf("profile \"%s\": %w", profileName, err))
+			continue
+		}
+
+		if partition := regionPartitions[profile.Region]; ssoPartition != "" && partition != ssoPartition {
+			problems = append(problems, fmt.Errorf("profile \"%s\": region \"%s\" is in partition %s, but SSO region \"%s\" is in partition %s", profileName, profile.Region, partition, c.SsoRegion, ssoPartition))
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// ValidateConfigs validates every config and reports all problems at once, prefixed with the config name.
+func ValidateConfigs(configs map[string]*Config) error {
+	configNames := make([]string, 0, len(configs))
+	for configName := range configs {
+		configNames = append(configNames, configName)
+	}
+	sort.Strings(configNames)
+
+	var problems []error
+	for _, configName := range configNames {
+		config := configs[configName]
+		if config == nil {
+			problems = append(problems, fmt.Errorf("config \"%s\" is empty", configName))
+			continue
+		}
+
+		if err := config.Validate(); err != nil {
+			for _, problem := range unwrapJoined(err) {
+				problems = append(problems, fmt.Errorf("config \"%s\": %w", configName, problem))
+			}
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+func unwrapJoined(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+
+	return []error{err}
+}
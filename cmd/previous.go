@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"log"
+)
+
+var previousBreakGlass string
+
+var previousCmd = &cobra.Command{
+	Use:               "previous",
+	Short:             "Switches back to the previous account and role",
+	Long:              `Writes credentials for the account and role used before the most recent one to the profile they were written to back then. "awsx -" does the same, so running it twice switches back and forth`,
+	Example:           "awsx -\nawsx previous",
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configName, previous, err := internal.PreviousUsageInformation()
+		if err != nil {
+			return err
+		}
+
+		configs, err := internal.ReadInternalConfig()
+		if err != nil {
+			return err
+		}
+
+		config, ok := configs[configName]
+		if !ok {
+			return fmt.Errorf("config \"%s\" of the previous role does not exist anymore", configName)
+		}
+
+		if previous.Profile == "" {
+			return errors.New("the history does not record which profile the previous role was written to")
+		}
+
+		profile, ok := config.Profiles[previous.Profile]
+		if !ok {
+			return fmt.Errorf("profile \"%s\" of the previous role does not exist in config \"%s\" anymore", previous.Profile, configName)
+		}
+
+		log.Printf("Switching profile %s back to account %s (%s) with role %s", profile.Name, previous.AccountName, previous.AccountId, previous.Role)
+
+		// pinning the profile to the previous role skips the pickers
+		pinned := *profile
+		pinned.AccountId = previous.AccountId
+		pinned.Role = previous.Role
+
+		oidcApi, ssoApi := internal.InitClients(config)
+		if err = start("previous", configName, &pinned, oidcApi, ssoApi, config, previousBreakGlass); err != nil {
+			return err
+		}
+
+		return internal.SetUsageInformationForConfig(configName, previous)
+	},
+}
+
+func init() {
+	previousCmd.Flags().StringVar(&previousBreakGlass, "break-glass", "", "Justification for using a sensitive role, recorded in the audit log")
+	rootCmd.AddCommand(previousCmd)
+}
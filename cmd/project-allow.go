@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var projectAllowCmd = &cobra.Command{
+	Use:               "allow",
+	Short:             "Allows the .awsx.yaml used in the current directory",
+	Long:              `Allows awsx and the shell hook to use the nearest .awsx.yaml above the current directory. The content of the file is remembered, it has to be allowed again after every change`,
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectConfig, err := internal.FindProjectConfig(".")
+		if err != nil {
+			return err
+		}
+		if projectConfig == nil {
+			return errors.New("no .awsx.yaml found")
+		}
+
+		if err = internal.AllowProject(projectConfig, true); err != nil {
+			return err
+		}
+
+		fmt.Printf("Allowed %s\n", projectConfig.Path)
+		return nil
+	},
+}
+
+func init() {
+	projectCmd.AddCommand(projectAllowCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var projectDenyCmd = &cobra.Command{
+	Use:               "deny",
+	Short:             "Revokes the permission to use the .awsx.yaml of the current directory",
+	Long:              `Revokes the permission to use the nearest .awsx.yaml above the current directory, given with "awsx project allow"`,
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectConfig, err := internal.FindProjectConfig(".")
+		if err != nil {
+			return err
+		}
+		if projectConfig == nil {
+			return errors.New("no .awsx.yaml found")
+		}
+
+		if err = internal.AllowProject(projectConfig, false); err != nil {
+			return err
+		}
+
+		fmt.Printf("Denied %s\n", projectConfig.Path)
+		return nil
+	},
+}
+
+func init() {
+	projectCmd.AddCommand(projectDenyCmd)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Shows the .awsx.yaml used in the current directory",
+	Long: `Shows the nearest .awsx.yaml above the current directory. When one is found, "awsx select", "awsx exec" and "awsx refresh" without arguments use its config, profile, account, role and region.
+An .awsx.yaml looks like:
+
+  config: work
+  profile: dev
+  account_id: "123456789012"
+  role: Developer
+  region: eu-west-1
+
+Only config is required. A file is only used after "awsx project allow", and again after every change to it`,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectConfig, err := internal.FindProjectConfig(".")
+		if err != nil {
+			return err
+		}
+
+		if projectConfig == nil {
+			fmt.Println("no .awsx.yaml found")
+			return nil
+		}
+
+		fmt.Printf("File:    %s\n", projectConfig.Path)
+		fmt.Printf("Config:  %s\n", projectConfig.Config)
+		if projectConfig.Profile != "" {
+			fmt.Printf("Profile: %s\n", projectConfig.Profile)
+		}
+		if projectConfig.AccountId != "" {
+			fmt.Printf("Account: %s\n", projectConfig.AccountId)
+			fmt.Printf("Role:    %s\n", projectConfig.Role)
+		}
+		if projectConfig.Region != "" {
+			fmt.Printf("Region:  %s\n", projectConfig.Region)
+		}
+		if !projectConfig.Allowed {
+			fmt.Println("Not allowed yet. review the file and run \"awsx project allow\" to use it")
+		}
+
+		return nil
+	},
+}
+
+var projectProfileCmd = &cobra.Command{
+	Use:               "profile",
+	Short:             "Prints the profile name of the .awsx.yaml used in the current directory",
+	Long:              `Prints the profile name of the nearest .awsx.yaml above the current directory, or nothing when there is none. Used by the shell hook. Fails for files that are not allowed`,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profileName, err := internal.ProjectProfileName(".")
+		if err != nil {
+			return err
+		}
+
+		if profileName != "" {
+			fmt.Println(profileName)
+		}
+		return nil
+	},
+}
+
+func init() {
+	projectCmd.AddCommand(projectProfileCmd)
+	rootCmd.AddCommand(projectCmd)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"time"
+)
+
+var pruneDryRun bool
+var pruneBlank bool
+var pruneOlderThan time.Duration
+
+var pruneCmd = &cobra.Command{
+	Use:               "prune",
+	Short:             "Removes expired profiles from the AWS credentials file",
+	Long:              `Removes the profiles awsx wrote to the AWS credentials file whose credentials have expired`,
+	Example:           "awsx prune --dry-run\nawsx prune --older-than 24h --blank",
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if pruneOlderThan < 0 {
+			return fmt.Errorf("invalid value \"%s\" for --older-than. it cannot be negative, credentials that have not expired yet would be pruned", pruneOlderThan)
+		}
+
+		pruned, err := internal.PruneAwsCredentials(pruneOlderThan, pruneBlank, pruneDryRun)
+		if err != nil {
+			return err
+		}
+
+		if len(pruned) == 0 {
+			fmt.Println("no expired profiles found")
+			return nil
+		}
+
+		action := "Removed"
+		if pruneBlank {
+			action = "Blanked"
+		}
+		if pruneDryRun {
+			action = "Would prune"
+		}
+
+		for _, profile := range pruned {
+			fmt.Printf("%s %s (expired at %s)\n", action, profile.Name, profile.ExpiredAt.Local().Format(time.DateTime))
+		}
+		return nil
+	},
+}
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Prints the profiles that would be pruned without changing the file")
+	pruneCmd.Flags().BoolVar(&pruneBlank, "blank", false, "Removes only the credentials of expired profiles and keeps their other settings")
+	pruneCmd.Flags().DurationVar(&pruneOlderThan, "older-than", 0, "Prunes only profiles that expired at least this long ago")
+	rootCmd.AddCommand(pruneCmd)
+}
@@ -6,21 +6,41 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/vahid-haghighat/awsx/cmd/internal"
 	"github.com/vahid-haghighat/awsx/utilities"
+	"os"
 )
 
+var refreshProfileName string
+var refreshNonInteractive bool
+var refreshBreakGlass string
+
 var refreshCmd = &cobra.Command{
 	Use:               "refresh",
 	Short:             "Refreshes your previously used credentials.",
 	Long:              `Refreshes your previously used credentials.`,
 	DisableAutoGenTag: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		configNames := []string{"default"}
-		if len(args) > 0 {
-			configNames = args
+		if refreshNonInteractive {
+			internal.DisablePrompts()
+		}
+
+		configNames := args
+		context := internal.ConfigContext{}
+		var projectConfig *internal.ProjectConfig
+		if len(args) == 0 {
+			var err error
+			context, projectConfig, err = defaultConfigContext()
+			if err != nil {
+				return err
+			}
+			configNames = []string{context.Config}
 		}
 
 		configs, err := internal.ReadInternalConfig()
-		if err != nil {
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		} else if err != nil && refreshNonInteractive {
+			return errors.New("no configuration found. run \"awsx config\" first")
+		} else if err != nil {
 			if err = configCmd.RunE(cmd, args); err != nil {
 				return err
 			}
@@ -33,12 +53,18 @@ var refreshCmd = &cobra.Command{
 
 		var errs []error
 
-		prompter := internal.Prompter{}
+		prompter, err := internal.DefaultSelector()
+		if err != nil {
+			return err
+		}
 
 	Configs:
 		for _, configName := range configNames {
 			config, ok := configs[configName]
-			if !ok {
+			if !ok && refreshNonInteractive {
+				errs = append(errs, errors.New(fmt.Sprintf("config \"%s\" does not exist", configName)))
+				continue Configs
+			} else if !ok {
 				if err = configCmd.RunE(cmd, []string{configName}); err != nil {
 					errs = append(errs, err)
 					continue Configs
@@ -47,8 +73,22 @@ var refreshCmd = &cobra.Command{
 				config = configs[configName]
 			}
 
+			profileName := refreshProfileName
+			if profileName == "" {
+				profileName = context.Profile
+			}
+
 			var profile *internal.Profile
-			if len(configs[configName].Profiles) > 1 {
+			if profileName != "" {
+				profile, ok = configs[configName].Profiles[profileName]
+				if !ok {
+					errs = append(errs, errors.New(fmt.Sprintf("profile \"%s\" does not exist in config \"%s\"", profileName, configName)))
+					continue Configs
+				}
+			} else if len(configs[configName].Profiles) > 1 && refreshNonInteractive {
+				errs = append(errs, errors.New(fmt.Sprintf("config \"%s\" has more than one profile. please specify one with --profile", configName)))
+				continue Configs
+			} else if len(configs[configName].Profiles) > 1 {
 				profiles := utilities.Keys(configs[configName].Profiles)
 				index, _, err := prompter.Select(fmt.Sprintf("Select the profile for config \"%s\"", configName), profiles, nil)
 				if err != nil {
@@ -68,13 +108,17 @@ var refreshCmd = &cobra.Command{
 				continue Configs
 			}
 
+			if projectConfig != nil {
+				profile = projectConfig.Apply(profile)
+			}
+
 			if profile.Region == "" {
 				errs = append(errs, errors.New(fmt.Sprintf("no region is set for profile \"%s\" in config \"%s\"", profile.Name, configName)))
 				continue Configs
 			}
 
 			oidcApi, ssoApi := internal.InitClients(configs[configName])
-			err = internal.RefreshCredentials(configName, profile, oidcApi, ssoApi, config, prompter)
+			err = internal.RefreshCredentials(configName, profile, oidcApi, ssoApi, config, prompter, refreshNonInteractive, refreshBreakGlass)
 			if err != nil {
 				errs = append(errs, err)
 			}
@@ -94,5 +138,8 @@ var refreshCmd = &cobra.Command{
 }
 
 func init() {
+	refreshCmd.Flags().StringVarP(&refreshProfileName, "profile", "p", "", "Name of the profile to refresh")
+	refreshCmd.Flags().BoolVar(&refreshNonInteractive, "non-interactive", false, "Refreshes the most recently used role without prompting")
+	refreshCmd.Flags().StringVar(&refreshBreakGlass, "break-glass", "", "Justification for using a sensitive role, required for them with --non-interactive")
 	rootCmd.AddCommand(refreshCmd)
 }
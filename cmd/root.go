@@ -3,22 +3,33 @@ package cmd
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
 	"github.com/vahid-haghighat/awsx/version"
 	"os"
 )
 
 var versionFlag bool
+var credentialsFileFlag string
+var awsConfigFileFlag string
+var awsxHomeFlag string
 
 var rootCmd = &cobra.Command{
 	Use:               "awsx",
 	Short:             "Retrieve short-living credentials via AWS SSO",
 	Long:              `Retrieve short-living credentials via AWS SSO`,
 	DisableAutoGenTag: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		internal.OverridePaths(credentialsFileFlag, awsConfigFileFlag, awsxHomeFlag)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if versionFlag {
 			fmt.Printf("v%s\n", version.Version)
 			return nil
 		}
+		// "awsx -" switches back like "cd -"
+		if len(args) == 1 && args[0] == "-" {
+			return previousCmd.RunE(cmd, nil)
+		}
 		return selectCmd.RunE(cmd, args)
 	},
 }
@@ -32,4 +43,7 @@ func Execute() {
 
 func init() {
 	rootCmd.Flags().BoolVarP(&versionFlag, "version", "v", false, "Prints awsx's version")
+	rootCmd.PersistentFlags().StringVar(&credentialsFileFlag, "credentials-file", "", "Path of the AWS credentials file. Defaults to $AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials")
+	rootCmd.PersistentFlags().StringVar(&awsConfigFileFlag, "aws-config-file", "", "Path of the AWS config file. Defaults to $AWS_CONFIG_FILE or ~/.aws/config")
+	rootCmd.PersistentFlags().StringVar(&awsxHomeFlag, "awsx-home", "", "Directory for awsx's config and cache. Defaults to $AWSX_HOME, $XDG_CONFIG_HOME/awsx and $XDG_CACHE_HOME/awsx, or ~/.config/awsx")
 }
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"time"
+)
+
+var scheduleInterval time.Duration
+
+var scheduleInstallCmd = &cobra.Command{
+	Use:               "install <config> <profile>",
+	Short:             "Schedules a non-interactive refresh",
+	Long:              `Installs a systemd user timer, or a crontab entry when systemd isn't available, that refreshes the most recently used role of a profile`,
+	Example:           "awsx schedule install default default --interval 30m",
+	Args:              cobra.ExactArgs(2),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configs, err := internal.ReadInternalConfig()
+		if err != nil {
+			return fmt.Errorf("no configuration found. run \"awsx config\" first")
+		}
+
+		config, ok := configs[args[0]]
+		if !ok {
+			return fmt.Errorf("config \"%s\" does not exist", args[0])
+		}
+
+		if _, ok = config.Profiles[args[1]]; !ok {
+			return fmt.Errorf("profile \"%s\" does not exist in config \"%s\"", args[1], args[0])
+		}
+
+		schedule, err := internal.InstallSchedule(args[0], args[1], scheduleInterval)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Installed %s schedule %s\n", schedule.Backend, schedule.Name)
+		return nil
+	},
+}
+
+func init() {
+	scheduleInstallCmd.Flags().DurationVarP(&scheduleInterval, "interval", "i", 30*time.Minute, "How often the credentials are refreshed")
+	scheduleCmd.AddCommand(scheduleInstallCmd)
+}
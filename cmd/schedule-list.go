@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var scheduleListCmd = &cobra.Command{
+	Use:               "list",
+	Short:             "Lists scheduled refreshes",
+	Long:              `Lists scheduled refreshes`,
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schedules, err := internal.ListSchedules()
+		if err != nil {
+			return err
+		}
+
+		if len(schedules) == 0 {
+			fmt.Println("no schedules installed")
+			return nil
+		}
+
+		for _, schedule := range schedules {
+			fmt.Printf("%s\t%s\t%s\n", schedule.Name, schedule.Backend, schedule.Command)
+		}
+		return nil
+	},
+}
+
+func init() {
+	scheduleCmd.AddCommand(scheduleListCmd)
+}
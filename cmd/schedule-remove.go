@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:               "remove <config> <profile>",
+	Short:             "Removes a scheduled refresh",
+	Long:              `Removes a scheduled refresh. The schedule name printed by "awsx schedule list" is accepted as well`,
+	Args:              cobra.RangeArgs(1, 2),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if len(args) == 2 {
+			name = internal.ScheduleName(args[0], args[1])
+		}
+
+		return internal.RemoveSchedule(name)
+	},
+}
+
+func init() {
+	scheduleCmd.AddCommand(scheduleRemoveCmd)
+}
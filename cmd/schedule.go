@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:               "schedule",
+	Short:             "Manages scheduled credential refreshes",
+	Long:              `Manages systemd user timers or crontab entries that refresh credentials before they expire`,
+	DisableAutoGenTag: true,
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+}
@@ -3,95 +3,171 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/service/sso"
 	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
 	"github.com/spf13/cobra"
 	"github.com/vahid-haghighat/awsx/cmd/internal"
 	"github.com/vahid-haghighat/awsx/utilities"
 	"log"
+	"os"
 	"time"
 )
 
+var selectBreakGlass string
+
 var selectCmd = &cobra.Command{
 	Use:               "select",
 	Short:             "Lets you select a profile from available profiles on AWS SSO",
 	Long:              `Lets you select a profile from available profiles on AWS SSO`,
 	DisableAutoGenTag: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) > 1 {
-			return errors.New("too many config names were specified. please pass only one config name")
+		configName, config, profile, err := selectProfile(cmd, args)
+		if err != nil {
+			return err
 		}
 
-		configs, err := internal.ReadInternalConfig()
-		if err != nil {
-			if err = configCmd.RunE(cmd, args); err != nil {
-				return err
-			}
-
-			configs, err = internal.ReadInternalConfig()
-			if err != nil {
-				return err
-			}
+		oidcApi, ssoApi := internal.InitClients(config)
+		return start("select", configName, profile, oidcApi, ssoApi, config, selectBreakGlass)
+	},
+}
+
+func init() {
+	selectCmd.Flags().StringVar(&selectBreakGlass, "break-glass", "", "Justification for using a sensitive role, recorded in the audit log")
+	rootCmd.AddCommand(selectCmd)
+}
+
+// selectProfile resolves the config and profile named by args, by the nearest allowed .awsx.yaml or by the current
+// context, and asks for the profile when the config has several.
+func selectProfile(cmd *cobra.Command, args []string) (string, *internal.Config, *internal.Profile, error) {
+	if len(args) > 1 {
+		return "", nil, nil, errors.New("too many config names were specified. please pass only one config name")
+	}
+
+	configs, err := internal.ReadInternalConfig()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", nil, nil, err
+	} else if err != nil {
+		if err = configCmd.RunE(cmd, args); err != nil {
+			return "", nil, nil, err
 		}
 
-		configName := "default"
+		configs, err = internal.ReadInternalConfig()
+		if err != nil {
+			return "", nil, nil, err
+		}
+	}
 
-		if len(args) == 1 {
-			configName = args[0]
+	context := internal.ConfigContext{}
+	var projectConfig *internal.ProjectConfig
+	if len(args) == 1 {
+		context.Config = args[0]
+	} else {
+		context, projectConfig, err = defaultConfigContext()
+		if err != nil {
+			return "", nil, nil, err
 		}
+	}
+	configName := context.Config
+
+	config, ok := configs[configName]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("config \"%s\" does not exist. run \"awsx config %s\" first", configName, configName)
+	}
 
-		var profile *internal.Profile
-		if len(configs[configName].Profiles) > 1 {
-			prompt := internal.Prompter{}
-			profiles := utilities.Keys(configs[configName].Profiles)
-			index, _, err := prompt.Select("Select the profile", profiles, nil)
-			if err != nil {
-				return err
-			}
-
-			profile = configs[configName].Profiles[profiles[index]]
-		} else {
-			for _, p := range configs[configName].Profiles {
-				profile = p
-			}
+	var profile *internal.Profile
+	if context.Profile != "" {
+		profile, ok = config.Profiles[context.Profile]
+		if !ok {
+			return "", nil, nil, fmt.Errorf("profile \"%s\" does not exist in config \"%s\"", context.Profile, configName)
+		}
+	} else if len(config.Profiles) > 1 {
+		prompt, err := internal.DefaultSelector()
+		if err != nil {
+			return "", nil, nil, err
 		}
 
-		if profile == nil {
-			return errors.New("no profile selected")
+		profiles := utilities.Keys(config.Profiles)
+		index, _, err := prompt.Select("Select the profile", profiles, nil)
+		if err != nil {
+			return "", nil, nil, err
 		}
 
-		if profile.Region == "" {
-			return errors.New("no region is set for this profile")
+		profile = config.Profiles[profiles[index]]
+	} else {
+		for _, p := range config.Profiles {
+			profile = p
 		}
+	}
 
-		oidcApi, ssoApi := internal.InitClients(configs[configName])
-		return start(configName, profile, oidcApi, ssoApi, configs[configName])
-	},
-}
+	if profile != nil && projectConfig != nil {
+		profile = projectConfig.Apply(profile)
+	}
 
-func init() {
-	rootCmd.AddCommand(selectCmd)
+	if profile == nil {
+		return "", nil, nil, errors.New("no profile selected")
+	}
+
+	if profile.Region == "" {
+		return "", nil, nil, errors.New("no region is set for this profile")
+	}
+
+	return configName, config, profile, nil
 }
 
-func start(configName string, profile *internal.Profile, oidcClient *ssooidc.Client, ssoClient *sso.Client, config *internal.Config) error {
-	clientInformation, _ := internal.ProcessClientInformation(configName, config.GetStartUrl(), oidcClient)
+// start writes credentials for the account and role of a pinned profile, or for the ones the user picks, to the profile.
+// breakGlassReason is the justification for sensitive roles given with --break-glass.
+func start(command string, configName string, profile *internal.Profile, oidcClient *ssooidc.Client, ssoClient *sso.Client, config *internal.Config, breakGlassReason string) error {
+	clientInformation, err := internal.ProcessClientInformation(configName, config, oidcClient)
+	if err != nil {
+		return err
+	}
 
-	promptSelector := internal.Prompter{}
-	accountInfo := internal.RetrieveAccountInfo(clientInformation, ssoClient, promptSelector)
-	roleInfo := internal.RetrieveRoleInfo(accountInfo, clientInformation, ssoClient, promptSelector)
-	_ = internal.SaveUsageInformation(configName, accountInfo, roleInfo)
+	accountId, roleName := &profile.AccountId, &profile.Role
+	accountName := ""
+	promptSelector, err := internal.DefaultSelector()
+	if err != nil {
+		return err
+	}
 
-	rci := &sso.GetRoleCredentialsInput{AccountId: accountInfo.AccountId, RoleName: roleInfo.RoleName, AccessToken: &clientInformation.AccessToken}
+	if !profile.Pinned() {
+		accountInfo, err := internal.RetrieveAccountInfo(configName, clientInformation, ssoClient, promptSelector)
+		if err != nil {
+			return err
+		}
+		roleInfo, err := internal.RetrieveRoleInfo(configName, accountInfo, clientInformation, ssoClient, promptSelector)
+		if err != nil {
+			return err
+		}
+		accountId, roleName, accountName = accountInfo.AccountId, roleInfo.RoleName, *accountInfo.AccountName
+	} else {
+		accountName = internal.LookupAccountName(configName, config, *accountId, clientInformation, ssoClient)
+	}
+
+	breakGlass := internal.BreakGlass{Reason: breakGlassReason, Selector: promptSelector}
+	justification, err := breakGlass.Confirm(config, *accountId, accountName, *roleName)
+	if err != nil {
+		return err
+	}
+
+	rci := &sso.GetRoleCredentialsInput{AccountId: accountId, RoleName: roleName, AccessToken: &clientInformation.AccessToken}
 	roleCredentials, err := ssoClient.GetRoleCredentials(context.Background(), rci)
 	if err != nil {
 		return err
 	}
+	// the credentials exist from here on, whether or not writing them succeeds
+	internal.AuditCredentials(command, configName, config, profile.Name, *accountId, accountName, *roleName, justification, roleCredentials.RoleCredentials)
 
-	err = internal.WriteAwsConfigFile(profile.Name, config, roleCredentials.RoleCredentials)
+	err = internal.WriteAwsConfigFile(profile, roleCredentials.RoleCredentials)
 	if err != nil {
 		return err
 	}
 
+	// only a role the credentials were written for belongs in the history
+	if !profile.Pinned() {
+		_ = internal.SetUsageInformationForConfig(configName, &internal.LastUsageInformation{AccountId: *accountId, AccountName: accountName, Role: *roleName, Profile: profile.Name})
+	}
+
 	log.Printf("Credentials expire at: %s\n", time.Unix(roleCredentials.RoleCredentials.Expiration/1000, 0))
 	return nil
 }
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+)
+
+var dockerFlagsHost string
+
+var dockerFlagsCmd = &cobra.Command{
+	Use:               "docker-flags",
+	Short:             "Prints docker run flags for the container credentials server",
+	Long:              `Prints the docker run flags that point containers at the server started with "awsx serve --ecs"`,
+	Example:           "docker run $(awsx serve docker-flags) amazon/aws-cli sts get-caller-identity",
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		information, err := internal.ReadEcsServerInformation()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(internal.FormatShellFlags(information.DockerFlags(dockerFlagsHost)))
+		return nil
+	},
+}
+
+func init() {
+	dockerFlagsCmd.Flags().StringVar(&dockerFlagsHost, "host", "", "Host the container reaches the server on. Uses the host network when empty")
+	serveCmd.AddCommand(dockerFlagsCmd)
+}
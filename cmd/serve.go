@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"github.com/vahid-haghighat/awsx/utilities"
+	"os"
+)
+
+var serveImds bool
+var serveEcs bool
+var serveAddress string
+var serveAccountId string
+var serveRoleName string
+var serveBreakGlass string
+var serveAllowRemote bool
+
+var serveCmd = &cobra.Command{
+	Use:               "serve",
+	Short:             "Serves SSO credentials to local tools over HTTP",
+	Long:              `Runs a local credential server for the selected account and role and keeps its credentials refreshed through AWS SSO`,
+	Example:           "awsx serve --imds my-sso-config\nawsx serve --ecs --address 127.0.0.1:1339 my-sso-config",
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return errors.New("too many config names were specified. please pass only one config name")
+		}
+
+		if serveImds == serveEcs {
+			return errors.New("please select exactly one server mode with --imds or --ecs")
+		}
+
+		if serveImds {
+			if err := internal.ValidateImdsAddress(serveAddress, serveAllowRemote); err != nil {
+				return err
+			}
+		}
+
+		configs, err := internal.ReadInternalConfig()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		} else if err != nil {
+			if err = configCmd.RunE(cmd, args); err != nil {
+				return err
+			}
+
+			configs, err = internal.ReadInternalConfig()
+			if err != nil {
+				return err
+			}
+		}
+
+		context := internal.ConfigContext{}
+		var projectConfig *internal.ProjectConfig
+		if len(args) == 1 {
+			context.Config = args[0]
+		} else {
+			context, projectConfig, err = defaultConfigContext()
+			if err != nil {
+				return err
+			}
+		}
+		configName := context.Config
+
+		config, ok := configs[configName]
+		if !ok {
+			return errors.New("config \"" + configName + "\" does not exist")
+		}
+
+		var profile *internal.Profile
+		if context.Profile != "" {
+			profile, ok = config.Profiles[context.Profile]
+			if !ok {
+				return fmt.Errorf("profile \"%s\" does not exist in config \"%s\"", context.Profile, configName)
+			}
+		} else if len(config.Profiles) > 1 {
+			prompt, err := internal.DefaultSelector()
+			if err != nil {
+				return err
+			}
+
+			profiles := utilities.Keys(config.Profiles)
+			index, _, err := prompt.Select("Select the profile", profiles, nil)
+			if err != nil {
+				return err
+			}
+
+			profile = config.Profiles[profiles[index]]
+		} else {
+			for _, p := range config.Profiles {
+				profile = p
+			}
+		}
+
+		if profile == nil {
+			return errors.New("no profile selected")
+		}
+
+		if projectConfig != nil {
+			profile = projectConfig.Apply(profile)
+		}
+
+		oidcApi, ssoApi := internal.InitClients(config)
+
+		accountId, roleName := serveAccountId, serveRoleName
+		if (accountId == "" || roleName == "") && profile.Pinned() {
+			accountId, roleName = profile.AccountId, profile.Role
+		}
+
+		clientInformation, err := internal.ProcessClientInformation(configName, config, oidcApi)
+		if err != nil {
+			return err
+		}
+
+		promptSelector, err := internal.DefaultSelector()
+		if err != nil {
+			return err
+		}
+
+		breakGlass := internal.BreakGlass{Reason: serveBreakGlass, Selector: promptSelector}
+		justification := ""
+		if accountId == "" || roleName == "" {
+			accountInfo, err := internal.RetrieveAccountInfo(configName, clientInformation, ssoApi, promptSelector)
+			if err != nil {
+				return err
+			}
+			roleInfo, err := internal.RetrieveRoleInfo(configName, accountInfo, clientInformation, ssoApi, promptSelector)
+			if err != nil {
+				return err
+			}
+			justification, err = breakGlass.Confirm(config, *accountInfo.AccountId, *accountInfo.AccountName, *roleInfo.RoleName)
+			if err != nil {
+				return err
+			}
+
+			_ = internal.SaveUsageInformation(configName, profile.Name, accountInfo, roleInfo)
+			accountId, roleName = *accountInfo.AccountId, *roleInfo.RoleName
+		} else {
+			accountName := internal.LookupAccountName(configName, config, accountId, clientInformation, ssoApi)
+			if justification, err = breakGlass.Confirm(config, accountId, accountName, roleName); err != nil {
+				return err
+			}
+		}
+
+		provider := internal.NewRoleCredentialsProvider(configName, config, accountId, roleName, oidcApi, ssoApi)
+		provider.Justification = justification
+		if _, err = provider.Retrieve(); err != nil {
+			return err
+		}
+
+		if serveEcs {
+			return internal.ServeEcs(serveAddress, provider)
+		}
+
+		return internal.ServeImds(serveAddress, serveAllowRemote, provider, profile.Region)
+	},
+}
+
+func init() {
+	serveCmd.Flags().BoolVar(&serveImds, "imds", false, "Serves credentials through an EC2 instance metadata (IMDSv2) compatible endpoint")
+	serveCmd.Flags().BoolVar(&serveEcs, "ecs", false, "Serves credentials through an ECS container credentials compatible endpoint")
+	serveCmd.Flags().StringVarP(&serveAddress, "address", "a", "127.0.0.1:1338", "Local address to listen on")
+	serveCmd.Flags().StringVar(&serveAccountId, "account", "", "Account id to serve credentials for. Prompts when empty")
+	serveCmd.Flags().StringVar(&serveRoleName, "role", "", "Role name to serve credentials for. Prompts when empty")
+	serveCmd.Flags().BoolVar(&serveAllowRemote, "allow-remote", false, "Lets --imds listen on an address other than loopback. Anyone who can reach it can read the credentials")
+	serveCmd.Flags().StringVar(&serveBreakGlass, "break-glass", "", "Justification for serving a sensitive role, recorded in the audit log")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vahid-haghighat/awsx/cmd/internal"
+	"log"
+)
+
+var useCmd = &cobra.Command{
+	Use:               "use <config>[/<profile>]",
+	Short:             "Sets the config and profile used when none is given",
+	Long:              `Sets the config, and optionally the profile, that select, refresh, serve and config use when no config is given. An .awsx.yaml in the current directory takes precedence`,
+	Example:           "awsx use work\nawsx use work/dev",
+	Args:              cobra.ExactArgs(1),
+	DisableAutoGenTag: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := internal.ParseConfigContext(args[0])
+		if err != nil {
+			return err
+		}
+
+		if err = internal.UseConfigContext(context); err != nil {
+			return err
+		}
+
+		fmt.Printf("Switched to %s\n", context)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(useCmd)
+}
+
+// defaultConfigContext returns the config and profile used when no config is given. The nearest .awsx.yaml wins over
+// the current context, its overrides are returned with it. A .awsx.yaml the user did not allow is an error.
+func defaultConfigContext() (internal.ConfigContext, *internal.ProjectConfig, error) {
+	projectConfig, err := internal.FindProjectConfig(".")
+	if err != nil {
+		return internal.ConfigContext{}, nil, err
+	}
+
+	if projectConfig != nil {
+		if !projectConfig.Allowed {
+			return internal.ConfigContext{}, nil, projectConfig.NotAllowedError()
+		}
+
+		log.Printf("Using %s", projectConfig.Path)
+		return internal.ConfigContext{Config: projectConfig.Config, Profile: projectConfig.Profile}, projectConfig, nil
+	}
+
+	context, err := internal.CurrentConfigContext()
+	return context, nil, err
+}